@@ -0,0 +1,77 @@
+// Command mapcheck loads level files the same way the demo does and reports problems found in them,
+// exiting with a non-zero status if any level file could not be loaded or has problems.
+//
+// Usage:
+//
+//	go run ./cmd/mapcheck [-textures game/resources/textures.json] [-archetypes game/resources/archetypes.json] level-file...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/harbdog/raycaster-go-demo/game/model"
+)
+
+func main() {
+	textures := flag.String("textures", "game/resources/textures.json", "texture manifest declaring the wall textures")
+	archetypes := flag.String("archetypes", "game/resources/archetypes.json", "archetype manifest declaring the sprite types")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] level-file...\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	manifestDir, manifestFile := filepath.Split(*textures)
+	manifest, err := model.LoadTextureManifest(os.DirFS(filepath.Clean(manifestDir)), manifestFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	archetypeDir, archetypeFile := filepath.Split(*archetypes)
+	archetypeManifest, err := model.LoadArchetypes(os.DirFS(filepath.Clean(archetypeDir)), archetypeFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	spriteTypes := archetypeManifest.Names()
+
+	textureIDs := make(map[int]struct{})
+	for _, entry := range manifest.Textures {
+		if entry.Kind == model.TextureWall {
+			textureIDs[entry.ID] = struct{}{}
+		}
+	}
+
+	failed := false
+	for _, mapFile := range flag.Args() {
+		m, _, err := model.LoadMapFile(mapFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", mapFile, err)
+			failed = true
+			continue
+		}
+
+		problems := model.CheckMap(m, textureIDs, spriteTypes)
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", mapFile, problem)
+		}
+		if len(problems) > 0 {
+			failed = true
+		} else {
+			fmt.Printf("%s: OK\n", mapFile)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
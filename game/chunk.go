@@ -0,0 +1,208 @@
+package game
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/harbdog/raycaster-go-demo/game/model"
+	"github.com/harbdog/raycaster-go/geom"
+)
+
+const (
+	// chunkSize is the width and height in map positions of the square chunks the map is split into
+	chunkSize = 16
+
+	// chunkRadius is how many chunks around the chunk of the player are kept active
+	chunkRadius = 2
+
+	// chunkLoadsPerTick is how many chunks are loaded each tick when the player moves into another chunk,
+	// spreading the loading of the outer chunks over several ticks to avoid frame hitches
+	chunkLoadsPerTick = 2
+)
+
+// mapChunk is a square area of the map that is loaded while it is near the player,
+// keeping the wall collision lines of each level and the minimap image of the area.
+// The wall levels themselves stay whole, since the camera renders them through the raycaster.Map interface.
+type mapChunk struct {
+	area           image.Rectangle
+	collisionLines [][]geom.Line
+	miniMap        *image.RGBA
+}
+
+// chunkAt returns the chunk coordinates of the map position
+func chunkAt(x, y float64) image.Point {
+	return image.Pt(int(math.Floor(x/chunkSize)), int(math.Floor(y/chunkSize)))
+}
+
+// chunkArea returns the map positions covered by the chunk, clipped to the map
+func (g *Game) chunkArea(chunk image.Point) image.Rectangle {
+	area := image.Rect(chunk.X*chunkSize, chunk.Y*chunkSize, (chunk.X+1)*chunkSize, (chunk.Y+1)*chunkSize)
+	return area.Intersect(image.Rect(0, 0, g.mapWidth, g.mapHeight))
+}
+
+// activeChunks returns the chunk coordinates around the chunk of the player that are kept active
+func (g *Game) activeChunks() image.Rectangle {
+	return image.Rect(
+		g.chunkCenter.X-chunkRadius, g.chunkCenter.Y-chunkRadius,
+		g.chunkCenter.X+chunkRadius+1, g.chunkCenter.Y+chunkRadius+1,
+	)
+}
+
+// isActiveAt returns true if the map position is in a chunk near the player, only entities
+// in active chunks are moved and take damage
+func (g *Game) isActiveAt(position *geom.Vector2) bool {
+	return chunkAt(position.X, position.Y).In(g.activeChunks())
+}
+
+// resetChunks unloads all chunks after the map changed, the chunks near the player are loaded again on the next update
+func (g *Game) resetChunks() {
+	g.chunks = make(map[image.Point]*mapChunk)
+	g.chunkQueue = nil
+	g.chunksCentered = false
+}
+
+// updateChunks loads the chunks near the player and unloads the chunks it moved away from.
+// The chunk of the player and the chunks next to it are loaded right away, the others are queued
+// and loaded a few at a time.
+func (g *Game) updateChunks() {
+	center := chunkAt(g.player.Position.X, g.player.Position.Y)
+	if !g.chunksCentered || center != g.chunkCenter {
+		g.chunkCenter, g.chunksCentered = center, true
+
+		active := g.activeChunks()
+		for chunk := range g.chunks {
+			if !chunk.In(active) {
+				delete(g.chunks, chunk)
+			}
+		}
+
+		g.chunkQueue = g.chunkQueue[:0]
+		for x := active.Min.X; x < active.Max.X; x++ {
+			for y := active.Min.Y; y < active.Max.Y; y++ {
+				chunk := image.Pt(x, y)
+				if _, ok := g.chunks[chunk]; ok || g.chunkArea(chunk).Empty() {
+					continue
+				}
+				if chunkDistance(chunk, center) <= 1 {
+					g.loadChunk(chunk)
+				} else {
+					g.chunkQueue = append(g.chunkQueue, chunk)
+				}
+			}
+		}
+
+		// load the queued chunks closest to the player first
+		sort.Slice(g.chunkQueue, func(i, j int) bool {
+			return chunkDistance(g.chunkQueue[i], center) < chunkDistance(g.chunkQueue[j], center)
+		})
+	}
+
+	for i := 0; i < chunkLoadsPerTick && len(g.chunkQueue) > 0; i++ {
+		g.loadChunk(g.chunkQueue[0])
+	}
+}
+
+// chunkDistance returns the number of chunks between two chunks, counting diagonal steps as one
+func chunkDistance(a, b image.Point) int {
+	dx, dy := a.X-b.X, a.Y-b.Y
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	if dx > dy {
+		return dx
+	}
+	return dy
+}
+
+// loadChunk calculates the collision lines and minimap image of the chunk, taking it off the queue
+func (g *Game) loadChunk(chunk image.Point) {
+	for i, queued := range g.chunkQueue {
+		if queued == chunk {
+			g.chunkQueue = append(g.chunkQueue[:i], g.chunkQueue[i+1:]...)
+			break
+		}
+	}
+
+	area := g.chunkArea(chunk)
+	c := &mapChunk{
+		area:           area,
+		collisionLines: make([][]geom.Line, g.mapObj.NumLevels()),
+		miniMap:        g.miniMapArea(area),
+	}
+	for levelNum := range c.collisionLines {
+		c.collisionLines[levelNum] = g.mapObj.GetAreaCollisionLines(levelNum, area, clipDistance)
+	}
+	g.chunks[chunk] = c
+}
+
+// reloadChunkAt loads the chunk of the map position again after the walls at the position changed
+func (g *Game) reloadChunkAt(x, y int) {
+	chunk := chunkAt(float64(x), float64(y))
+	if _, ok := g.chunks[chunk]; ok {
+		g.loadChunk(chunk)
+	}
+}
+
+// indexSprite moves the entity of a sprite to the chunk it is in, after it was added or moved
+func (g *Game) indexSprite(entity *model.Entity) {
+	chunk := chunkAt(entity.Position.X, entity.Position.Y)
+	if current, ok := g.spriteChunkOf[entity]; ok {
+		if current == chunk {
+			return
+		}
+		g.unindexSprite(entity)
+	}
+
+	entities, ok := g.spriteChunks[chunk]
+	if !ok {
+		entities = make(map[*model.Entity]struct{})
+		g.spriteChunks[chunk] = entities
+	}
+	entities[entity] = struct{}{}
+	g.spriteChunkOf[entity] = chunk
+}
+
+// unindexSprite removes the entity of a sprite from the chunk it is in, after the sprite was deleted
+func (g *Game) unindexSprite(entity *model.Entity) {
+	chunk, ok := g.spriteChunkOf[entity]
+	if !ok {
+		return
+	}
+	delete(g.spriteChunkOf, entity)
+	delete(g.spriteChunks[chunk], entity)
+	if len(g.spriteChunks[chunk]) == 0 {
+		delete(g.spriteChunks, chunk)
+	}
+}
+
+// spritesInChunks returns the entities of the sprites in the chunks from minChunk up to and including maxChunk
+func (g *Game) spritesInChunks(minChunk, maxChunk image.Point) []*model.Entity {
+	entities := []*model.Entity{}
+	for cx := minChunk.X; cx <= maxChunk.X; cx++ {
+		for cy := minChunk.Y; cy <= maxChunk.Y; cy++ {
+			for entity := range g.spriteChunks[image.Pt(cx, cy)] {
+				entities = append(entities, entity)
+			}
+		}
+	}
+	return entities
+}
+
+// chunkCollisionLines returns the wall collision lines of a level in the chunk. Chunks still queued to load,
+// and those just outside the active chunks that entities at the edge can move into, are loaded right away.
+// Chunks further away have no collision lines, since nothing moves there.
+func (g *Game) chunkCollisionLines(chunk image.Point, levelNum int) []geom.Line {
+	c, ok := g.chunks[chunk]
+	if !ok {
+		if !chunk.In(g.activeChunks().Inset(-1)) || g.chunkArea(chunk).Empty() {
+			return nil
+		}
+		g.loadChunk(chunk)
+		c = g.chunks[chunk]
+	}
+	return c.collisionLines[levelNum]
+}
@@ -1,6 +1,7 @@
 package game
 
 import (
+	"image"
 	"math"
 	"sort"
 
@@ -29,14 +30,33 @@ func (g *Game) getValidMove(entity *model.Entity, moveX, moveY, moveZ float64, c
 	intersectPoints := []geom.Vector2{}
 	collisionEntities := []*EntityCollision{}
 
-	// check wall collisions
-	for _, borderLine := range g.collisionMap {
-		// TODO: only check intersection of nearby wall cells instead of all of them
+	// check wall collisions with the map edge, and the walls of levels within the height of the entity
+	minZ, maxZ := zEntityMinMax(newZ, entity)
+	for _, borderLine := range g.boundaryLines {
 		if px, py, ok := geom.LineIntersection(moveLine, borderLine); ok {
 			intersectPoints = append(intersectPoints, geom.Vector2{X: px, Y: py})
 		}
 	}
 
+	// only the chunks around the move line have wall collision lines and sprites that it can intersect,
+	// as long as the combined collision radius of the entity and a sprite is below 1
+	minChunk := chunkAt(math.Min(posX, newX)-1, math.Min(posY, newY)-1)
+	maxChunk := chunkAt(math.Max(posX, newX)+1, math.Max(posY, newY)+1)
+	for levelNum := 0; levelNum < g.mapObj.NumLevels(); levelNum++ {
+		if !zLevelIntersects(levelNum, minZ, maxZ) {
+			continue
+		}
+		for cx := minChunk.X; cx <= maxChunk.X; cx++ {
+			for cy := minChunk.Y; cy <= maxChunk.Y; cy++ {
+				for _, borderLine := range g.chunkCollisionLines(image.Pt(cx, cy), levelNum) {
+					if px, py, ok := geom.LineIntersection(moveLine, borderLine); ok {
+						intersectPoints = append(intersectPoints, geom.Vector2{X: px, Y: py})
+					}
+				}
+			}
+		}
+	}
+
 	// check sprite against player collision
 	if entity != g.player.Entity && entity.Parent != g.player.Entity && entity.CollisionRadius > 0 {
 		// TODO: only check for collision if player is somewhat nearby
@@ -65,14 +85,13 @@ func (g *Game) getValidMove(entity *model.Entity, moveX, moveY, moveZ float64, c
 	}
 
 	// check sprite collisions
-	for sprite := range g.sprites {
-		// TODO: only check intersection of nearby sprites instead of all of them
-		if entity == sprite.Entity || entity.Parent == sprite.Entity || entity.CollisionRadius <= 0 || sprite.CollisionRadius <= 0 {
+	for _, sprite := range g.spritesInChunks(minChunk, maxChunk) {
+		if entity == sprite || entity.Parent == sprite || entity.CollisionRadius <= 0 || sprite.CollisionRadius <= 0 {
 			continue
 		}
 
 		// quick check if intersects in Z-plane
-		zIntersect := zEntityIntersection(newZ, entity, sprite.Entity)
+		zIntersect := zEntityIntersection(newZ, entity, sprite)
 
 		// check if movement line intersects with combined collision radii
 		combinedCircle := geom.Circle{X: sprite.Position.X, Y: sprite.Position.Y, Radius: sprite.CollisionRadius + entity.CollisionRadius}
@@ -87,7 +106,7 @@ func (g *Game) getValidMove(entity *model.Entity, moveX, moveY, moveZ float64, c
 
 				for _, intersect := range intersectPoints {
 					collisionEntities = append(
-						collisionEntities, &EntityCollision{entity: sprite.Entity, collision: &intersect, collisionZ: zIntersect},
+						collisionEntities, &EntityCollision{entity: sprite, collision: &intersect, collisionZ: zIntersect},
 					)
 				}
 			}
@@ -178,8 +197,7 @@ func (g *Game) getValidMove(entity *model.Entity, moveX, moveY, moveZ float64, c
 		iy = int(newY)
 	}
 
-	worldMap := g.mapObj.Level(0)
-	if worldMap[ix][iy] <= 0 {
+	if !g.isWallAt(ix, iy, minZ, maxZ) {
 		posX = newX
 		posY = newY
 	} else {
@@ -189,6 +207,22 @@ func (g *Game) getValidMove(entity *model.Entity, moveX, moveY, moveZ float64, c
 	return &geom.Vector2{X: posX, Y: posY}, isCollision, collisionEntities
 }
 
+// isWallAt returns true if there is a wall at the map position on any level within the Z range
+func (g *Game) isWallAt(x, y int, minZ, maxZ float64) bool {
+	for levelNum := 0; levelNum < g.mapObj.NumLevels(); levelNum++ {
+		if zLevelIntersects(levelNum, minZ, maxZ) && g.mapObj.Level(levelNum)[x][y] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// zLevelIntersects returns true if the Z range intersects the height of the walls of a level,
+// the walls of level n being from Z=n up to Z=n+1 (an entity merely touching either end does not intersect)
+func zLevelIntersects(levelNum int, minZ, maxZ float64) bool {
+	return minZ < float64(levelNum+1) && maxZ > float64(levelNum)
+}
+
 // zEntityIntersection returns the best positionZ intersection point on the target from the source (-1 if no intersection)
 func zEntityIntersection(sourceZ float64, source, target *model.Entity) float64 {
 	srcMinZ, srcMaxZ := zEntityMinMax(sourceZ, source)
@@ -0,0 +1,94 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/harbdog/raycaster-go"
+	"github.com/harbdog/raycaster-go-demo/game/model"
+)
+
+func TestZLevelIntersects(t *testing.T) {
+	tests := []struct {
+		name            string
+		positionZ       float64
+		anchor          raycaster.SpriteAnchor
+		collisionHeight float64
+		wantLevels      []bool
+	}{
+		{
+			name:            "standing on the ground",
+			positionZ:       0,
+			anchor:          raycaster.AnchorBottom,
+			collisionHeight: 0.5,
+			wantLevels:      []bool{true, false, false},
+		},
+		{
+			name:            "standing exactly at the top of the first level",
+			positionZ:       1.0,
+			anchor:          raycaster.AnchorBottom,
+			collisionHeight: 0.5,
+			wantLevels:      []bool{false, true, false},
+		},
+		{
+			name:            "reaching exactly to the top of the first level",
+			positionZ:       0.5,
+			anchor:          raycaster.AnchorBottom,
+			collisionHeight: 0.5,
+			wantLevels:      []bool{true, false, false},
+		},
+		{
+			name:            "spanning two levels",
+			positionZ:       0.75,
+			anchor:          raycaster.AnchorBottom,
+			collisionHeight: 0.5,
+			wantLevels:      []bool{true, true, false},
+		},
+		{
+			name:            "bat hanging exactly at the top of the first level",
+			positionZ:       1.0,
+			anchor:          raycaster.AnchorTop,
+			collisionHeight: 0.2,
+			wantLevels:      []bool{true, false, false},
+		},
+		{
+			name:            "bat hanging above the first level",
+			positionZ:       1.5,
+			anchor:          raycaster.AnchorTop,
+			collisionHeight: 0.2,
+			wantLevels:      []bool{false, true, false},
+		},
+		{
+			name:            "centered across a level boundary",
+			positionZ:       2.0,
+			anchor:          raycaster.AnchorCenter,
+			collisionHeight: 0.5,
+			wantLevels:      []bool{false, true, true},
+		},
+		{
+			name:            "zero height inside a level",
+			positionZ:       0.5,
+			anchor:          raycaster.AnchorBottom,
+			collisionHeight: 0,
+			wantLevels:      []bool{true, false, false},
+		},
+		{
+			name:            "zero height exactly at a level boundary",
+			positionZ:       1.0,
+			anchor:          raycaster.AnchorBottom,
+			collisionHeight: 0,
+			wantLevels:      []bool{false, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity := &model.Entity{Anchor: tt.anchor, CollisionHeight: tt.collisionHeight}
+			minZ, maxZ := zEntityMinMax(tt.positionZ, entity)
+			for levelNum, want := range tt.wantLevels {
+				if got := zLevelIntersects(levelNum, minZ, maxZ); got != want {
+					t.Errorf("zLevelIntersects(%d, %v, %v) = %v, want %v", levelNum, minZ, maxZ, got, want)
+				}
+			}
+		})
+	}
+}
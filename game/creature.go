@@ -0,0 +1,54 @@
+package game
+
+import (
+	"github.com/harbdog/raycaster-go-demo/game/model"
+)
+
+// updateCreatureClip plays the walk clip of a creature sprite while it moves and the idle clip while it stands,
+// once any hurt clip it was playing has finished
+func (g *Game) updateCreatureClip(s *model.Sprite) {
+	if s.AnimationClip() == "" || isCreatureBusy(s) {
+		return
+	}
+	if s.Velocity != 0 && s.HasAnimationClip(model.ClipWalk) {
+		s.PlayAnimation(model.ClipWalk)
+	} else {
+		s.PlayAnimation(model.ClipIdle)
+	}
+}
+
+// isCreatureBusy returns true if the creature sprite is playing a clip it should not move during,
+// such as getting hurt or dying
+func isCreatureBusy(s *model.Sprite) bool {
+	switch s.AnimationClip() {
+	case model.ClipHurt:
+		return !s.IsAnimationFinished()
+	case model.ClipDie:
+		return true
+	}
+	return false
+}
+
+// hurtSprite plays the hurt clip of the sprite hit by the entity, if it has one
+func (g *Game) hurtSprite(entity *model.Entity) {
+	for s := range g.sprites {
+		if s.Entity == entity {
+			s.PlayAnimation(model.ClipHurt)
+			return
+		}
+	}
+}
+
+// killSprite plays the die clip of the sprite, removing it when the clip finishes,
+// sprites without a die clip are removed right away
+func (g *Game) killSprite(s *model.Sprite) {
+	if !s.PlayAnimation(model.ClipDie) {
+		g.deleteSprite(s)
+		return
+	}
+	s.OnAnimationFinished = func(s *model.Sprite, clip string) {
+		if clip == model.ClipDie {
+			g.deleteSprite(s)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package game
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/harbdog/raycaster-go-demo/game/model"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// decalSize is the width and height of an impact decal on a wall texture
+const decalSize = texWidth / 4
+
+// decalFace is the face of a wall cell that decals are put on
+type decalFace struct {
+	cell model.MapCell
+	face model.Face
+}
+
+// wallDecals are the decals on a wall face, drawn on an overlay that is composited over the wall texture
+// into an image of its own so the shared wall texture is left as it is
+type wallDecals struct {
+	// marks are the texture positions of the decals, oldest first
+	marks   []image.Point
+	overlay *ebiten.Image
+	image   *ebiten.Image
+	// base is the wall texture the image was composited with, to composite again when it changes
+	base *ebiten.Image
+}
+
+// AddDecal puts an impact decal on the face of the wall cell at the position on the face (0 to 1 from the left)
+// and height in the level (0 to 1 from the bottom). The oldest decal is removed when over the decal budget.
+func (t *TextureHandler) AddDecal(cell model.MapCell, face model.Face, u, v float64) {
+	if t.maxDecals <= 0 {
+		return
+	}
+	if t.decalTex == nil {
+		t.decalTex = newDecalTexture()
+	}
+
+	for len(t.decalOrder) >= t.maxDecals {
+		t.removeOldestDecal()
+	}
+
+	key := decalFace{cell: cell, face: face}
+	decals, ok := t.decals[key]
+	if !ok {
+		decals = &wallDecals{
+			overlay: ebiten.NewImage(texWidth, texWidth),
+			image:   ebiten.NewImage(texWidth, texWidth),
+		}
+		t.decals[key] = decals
+	}
+
+	mark := image.Pt(int(u*texWidth), int((1-v)*texWidth))
+	decals.marks = append(decals.marks, mark)
+	t.drawDecal(decals.overlay, mark)
+	decals.base = nil
+	t.decalOrder = append(t.decalOrder, key)
+
+	t.updateDecals()
+}
+
+// RemoveDecals removes the decals on all faces of the wall cell, such as when the wall is destroyed
+func (t *TextureHandler) RemoveDecals(cell model.MapCell) {
+	removed := false
+	for _, face := range []model.Face{model.FaceNorth, model.FaceSouth, model.FaceEast, model.FaceWest} {
+		key := decalFace{cell: cell, face: face}
+		if decals, ok := t.decals[key]; ok {
+			decals.overlay.Dispose()
+			decals.image.Dispose()
+			delete(t.decals, key)
+			removed = true
+		}
+	}
+	if !removed {
+		return
+	}
+
+	order := t.decalOrder[:0]
+	for _, key := range t.decalOrder {
+		if key.cell != cell {
+			order = append(order, key)
+		}
+	}
+	t.decalOrder = order
+}
+
+// removeOldestDecal removes the decal that was added first, which is also the first decal on its face
+func (t *TextureHandler) removeOldestDecal() {
+	key := t.decalOrder[0]
+	t.decalOrder = t.decalOrder[1:]
+
+	decals := t.decals[key]
+	decals.marks = decals.marks[1:]
+	if len(decals.marks) == 0 {
+		decals.overlay.Dispose()
+		decals.image.Dispose()
+		delete(t.decals, key)
+		return
+	}
+
+	decals.overlay.Clear()
+	for _, mark := range decals.marks {
+		t.drawDecal(decals.overlay, mark)
+	}
+	decals.base = nil
+}
+
+// drawDecal draws the decal texture centered at the texture position
+func (t *TextureHandler) drawDecal(overlay *ebiten.Image, mark image.Point) {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(mark.X-decalSize/2), float64(mark.Y-decalSize/2))
+	overlay.DrawImage(t.decalTex, op)
+}
+
+// updateDecals composites the decals over the wall textures of their faces that changed,
+// such as after adding a decal or when an animated wall texture moves to its next frame
+func (t *TextureHandler) updateDecals() {
+	for key, decals := range t.decals {
+		base := t.wallTextureAt(key.cell.X, key.cell.Y, key.cell.Level, key.face)
+		if base == decals.base {
+			continue
+		}
+		decals.base = base
+
+		decals.image.Clear()
+		if base != nil {
+			decals.image.DrawImage(base, nil)
+		}
+		decals.image.DrawImage(decals.overlay, nil)
+	}
+}
+
+// decalTextureAt returns the wall texture with decals of the face of the wall cell (nil if it has no decals)
+func (t *TextureHandler) decalTextureAt(x, y, levelNum int, face model.Face) *ebiten.Image {
+	if len(t.decals) == 0 {
+		return nil
+	}
+	if decals, ok := t.decals[decalFace{cell: model.MapCell{Level: levelNum, X: x, Y: y}, face: face}]; ok {
+		return decals.image
+	}
+	return nil
+}
+
+// newDecalTexture creates a round scorch mark fading out towards its edge
+func newDecalTexture() *ebiten.Image {
+	img := image.NewRGBA(image.Rect(0, 0, decalSize, decalSize))
+	radius := float64(decalSize) / 2
+	for x := 0; x < decalSize; x++ {
+		for y := 0; y < decalSize; y++ {
+			d := math.Hypot(float64(x)+0.5-radius, float64(y)+0.5-radius) / radius
+			if d >= 1 {
+				continue
+			}
+			a := uint8(220 * (1 - d) * (1 - d))
+			// premultiplied alpha for the dark scorch color
+			img.SetRGBA(x, y, color.RGBA{R: a / 8, G: a / 10, B: a / 12, A: a})
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// addImpactDecal puts a decal on the face of the wall cell at the point it was hit
+func (g *Game) addImpactDecal(cell model.MapCell, face model.Face, hitX, hitY, hitZ float64) {
+	if cell.Level == 0 && g.mapObj.DoorAt(cell.X, cell.Y) != nil {
+		return
+	}
+
+	// position on the face as seen from the front, matching how the raycaster maps wall textures
+	var u float64
+	switch face {
+	case model.FaceWest:
+		u = 1 - (hitY - math.Floor(hitY))
+	case model.FaceEast:
+		u = hitY - math.Floor(hitY)
+	case model.FaceNorth:
+		u = hitX - math.Floor(hitX)
+	case model.FaceSouth:
+		u = 1 - (hitX - math.Floor(hitX))
+	}
+	v := hitZ - float64(cell.Level)
+
+	g.tex.AddDecal(cell, face, u, v)
+}
+
+// castWall follows the line from the position at the heading and pitch angles through the map cells
+// to the first wall it hits within the (horizontal) distance, returning the wall cell,
+// the face of the cell that was hit and the point it was hit at
+func (g *Game) castWall(x, y, z, angle, pitch, maxDist float64) (model.MapCell, model.Face, float64, float64, float64, bool) {
+	dirX, dirY := math.Cos(angle), math.Sin(angle)
+	mapX, mapY := int(math.Floor(x)), int(math.Floor(y))
+
+	deltaX, deltaY := math.Inf(1), math.Inf(1)
+	if dirX != 0 {
+		deltaX = math.Abs(1 / dirX)
+	}
+	if dirY != 0 {
+		deltaY = math.Abs(1 / dirY)
+	}
+
+	stepX, sideX := 1, (float64(mapX)+1-x)*deltaX
+	if dirX < 0 {
+		stepX, sideX = -1, (x-float64(mapX))*deltaX
+	}
+	stepY, sideY := 1, (float64(mapY)+1-y)*deltaY
+	if dirY < 0 {
+		stepY, sideY = -1, (y-float64(mapY))*deltaY
+	}
+
+	for {
+		var dist float64
+		var face model.Face
+		if sideX < sideY {
+			dist = sideX
+			sideX += deltaX
+			mapX += stepX
+			face = model.FaceWest
+			if stepX < 0 {
+				face = model.FaceEast
+			}
+		} else {
+			dist = sideY
+			sideY += deltaY
+			mapY += stepY
+			face = model.FaceNorth
+			if stepY < 0 {
+				face = model.FaceSouth
+			}
+		}
+
+		if dist > maxDist || mapX < 0 || mapY < 0 || mapX >= g.mapWidth || mapY >= g.mapHeight {
+			return model.MapCell{}, 0, 0, 0, 0, false
+		}
+
+		hitZ := z + dist*math.Tan(pitch)
+		levelNum := int(math.Floor(hitZ))
+		if hitZ < 0 {
+			return model.MapCell{}, 0, 0, 0, 0, false
+		}
+		if levelNum < g.mapObj.NumLevels() && g.mapObj.Level(levelNum)[mapX][mapY] > 0 {
+			cell := model.MapCell{Level: levelNum, X: mapX, Y: mapY}
+			return cell, face, x + dist*dirX, y + dist*dirY, hitZ, true
+		}
+	}
+}
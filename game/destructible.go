@@ -0,0 +1,106 @@
+package game
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/harbdog/raycaster-go-demo/game/model"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// projectileWallDamage is how much health a projectile takes from a destructible wall it hits
+	projectileWallDamage = 1.0
+
+	// debrisFrames is the number of frames of the debris effect, with debrisFrameSize the width and height of each frame
+	debrisFrames    = 8
+	debrisFrameSize = 64
+	debrisPieces    = 16
+)
+
+// hitWall wears down the destructible wall the projectile hit within its last move,
+// leaving an impact decal on any wall that is still standing
+func (g *Game) hitWall(p *model.Projectile) {
+	// projectiles move at most their velocity (distance/second) times the longest update
+	cell, face, hitX, hitY, hitZ, ok := g.castWall(p.Position.X, p.Position.Y, p.PositionZ, p.Angle, p.Pitch, p.Velocity*maxUpdateDelta+1)
+	if !ok {
+		return
+	}
+
+	if w := g.mapObj.DestructibleAt(cell.Level, cell.X, cell.Y); w != nil {
+		g.damageWall(w, projectileWallDamage)
+		if w.IsDestroyed() {
+			return
+		}
+	}
+	g.addImpactDecal(cell, face, hitX, hitY, hitZ)
+}
+
+// damageWall lowers the health of the destructible wall, changing its texture to the damage stage it reached.
+// Walls without health left are removed from the map along with their decals, leaving a debris effect.
+func (g *Game) damageWall(w *model.DestructibleWall, damage float64) {
+	if w.IsDestroyed() || !w.Damage(damage) {
+		return
+	}
+
+	g.mapObj.SetWall(w.Level, w.X, w.Y, w.StageTexNum())
+	if !w.IsDestroyed() {
+		return
+	}
+
+	g.tex.RemoveDecals(w.MapCell)
+	g.reloadChunkAt(w.X, w.Y)
+
+	effect, err := g.newEffectByType("debris", float64(w.X)+0.5, float64(w.Y)+0.5, float64(w.Level)+0.5)
+	if err == nil {
+		g.addEffect(effect)
+	}
+}
+
+// newDebrisSheet creates the frames of wall pieces bursting out and falling down, in a single row
+func newDebrisSheet() *ebiten.Image {
+	img := image.NewRGBA(image.Rect(0, 0, debrisFrames*debrisFrameSize, debrisFrameSize))
+
+	// the same pieces every time so the effect looks the same for every wall
+	rnd := rand.New(rand.NewSource(1))
+	type piece struct {
+		vx, vy float64
+		size   int
+		shade  uint8
+	}
+	pieces := make([]piece, debrisPieces)
+	for i := range pieces {
+		angle := rnd.Float64() * 2 * math.Pi
+		speed := 0.4 + rnd.Float64()*0.6
+		pieces[i] = piece{
+			vx: math.Cos(angle) * speed, vy: math.Sin(angle)*speed - 0.3,
+			size: 2 + rnd.Intn(4), shade: uint8(70 + rnd.Intn(60)),
+		}
+	}
+
+	center := float64(debrisFrameSize) / 2
+	for frame := 0; frame < debrisFrames; frame++ {
+		t := float64(frame) / float64(debrisFrames-1)
+		alpha := 1 - t*0.6
+		for _, p := range pieces {
+			// pieces fly outward and fall with gravity
+			px := center + p.vx*t*center*0.9
+			py := center + (p.vy*t+0.8*t*t)*center*0.9
+			shade := uint8(float64(p.shade) * alpha)
+			c := color.RGBA{R: shade, G: uint8(float64(shade) * 0.85), B: uint8(float64(shade) * 0.7), A: uint8(255 * alpha)}
+			for x := 0; x < p.size; x++ {
+				for y := 0; y < p.size; y++ {
+					ix, iy := int(px)+x, int(py)+y
+					if ix >= 0 && iy >= 0 && ix < debrisFrameSize && iy < debrisFrameSize {
+						img.SetRGBA(frame*debrisFrameSize+ix, iy, c)
+					}
+				}
+			}
+		}
+	}
+
+	return ebiten.NewImageFromImage(img)
+}
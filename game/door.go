@@ -0,0 +1,124 @@
+package game
+
+import (
+	"github.com/harbdog/raycaster-go-demo/game/model"
+	"github.com/harbdog/raycaster-go/geom"
+)
+
+const (
+	// distance from the player to a door cell center for the player to be able to open it
+	doorInteractDistance = 1.5
+
+	// distance from the player to a door cell center for an automatic door to open
+	doorAutoDistance = 1.5
+)
+
+// updateDoors moves the doors along, opens automatic doors the player walks up to,
+// and updates the map walls and collision lines when a door starts or stops letting entities through
+func (g *Game) updateDoors(dt float64) {
+	for _, door := range g.mapObj.Doors() {
+		wasPassable := door.IsPassable()
+
+		if door.Auto && g.doorDistance(door) <= doorAutoDistance {
+			door.Open()
+		}
+
+		canClose := false
+		if door.State() == model.DoorOpen {
+			canClose = !g.isCellOccupied(door.X, door.Y)
+		}
+		door.Update(canClose, dt)
+
+		if door.IsPassable() != wasPassable {
+			g.updateDoorWall(door)
+		}
+	}
+}
+
+// interact opens or closes the nearest door in front of the player
+func (g *Game) interact() {
+	var door *model.Door
+	lookLine := geom.LineFromAngle(g.player.Position.X, g.player.Position.Y, g.player.Angle, doorInteractDistance)
+	for _, d := range g.mapObj.Doors() {
+		if g.doorDistance(d) > doorInteractDistance {
+			continue
+		}
+
+		// the player needs to be looking at the door cell
+		cellLines := geom.Rect(float64(d.X), float64(d.Y), 1, 1)
+		for _, cellLine := range cellLines {
+			if _, _, ok := geom.LineIntersection(lookLine, cellLine); ok {
+				door = d
+				break
+			}
+		}
+		if door != nil {
+			break
+		}
+	}
+
+	if door == nil {
+		return
+	}
+
+	switch door.State() {
+	case model.DoorClosed, model.DoorClosing:
+		if !door.Open() {
+			println("locked!")
+		}
+	default:
+		if g.isCellOccupied(door.X, door.Y) {
+			return
+		}
+
+		wasPassable := door.IsPassable()
+		door.Close()
+		if wasPassable {
+			g.updateDoorWall(door)
+		}
+	}
+}
+
+// updateDoorWall clears the wall of the door cell while it is passable, and restores it otherwise,
+// updating the collision lines of the chunk of the door
+func (g *Game) updateDoorWall(door *model.Door) {
+	if door.IsPassable() {
+		g.mapObj.SetWall(0, door.X, door.Y, 0)
+	} else {
+		g.mapObj.SetWall(0, door.X, door.Y, door.TexNum)
+	}
+	g.reloadChunkAt(door.X, door.Y)
+}
+
+// doorDistance returns the distance from the player to the center of the door cell
+func (g *Game) doorDistance(door *model.Door) float64 {
+	return geom.Distance(g.player.Position.X, g.player.Position.Y, float64(door.X)+0.5, float64(door.Y)+0.5)
+}
+
+// isCellOccupied returns true if the player or any sprite collides with the map cell
+func (g *Game) isCellOccupied(x, y int) bool {
+	if entityInCell(g.player.Entity, x, y) {
+		return true
+	}
+	// only sprites in the chunks around the cell can reach into it
+	minChunk := chunkAt(float64(x-1), float64(y-1))
+	maxChunk := chunkAt(float64(x+2), float64(y+2))
+	for _, sprite := range g.spritesInChunks(minChunk, maxChunk) {
+		if sprite.CollisionRadius > 0 && entityInCell(sprite, x, y) {
+			return true
+		}
+	}
+	return false
+}
+
+func entityInCell(entity *model.Entity, x, y int) bool {
+	r := entity.CollisionRadius + clipDistance
+	return entity.Position.X+r > float64(x) && entity.Position.X-r < float64(x+1) &&
+		entity.Position.Y+r > float64(y) && entity.Position.Y-r < float64(y+1)
+}
+
+// updateCollisionMap recalculates the map edge collision lines and unloads the chunks after the map changed
+func (g *Game) updateCollisionMap() {
+	g.boundaryLines = g.mapObj.GetBoundaryLines(clipDistance)
+	g.resetChunks()
+}
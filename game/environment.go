@@ -0,0 +1,101 @@
+package game
+
+import (
+	"image/color"
+
+	"github.com/harbdog/raycaster-go-demo/game/model"
+)
+
+// initEnvironment sets the demo environment used for the settings a map does not set in its own environment
+func (g *Game) initEnvironment() {
+	renderDistance := g.renderDistance
+	lightFalloff, illumination := -200.0, 500.0
+
+	g.defaultEnvironment = model.Environment{
+		Sky:            "sky",
+		Floor:          "grass",
+		RenderDistance: &renderDistance,
+		LightFalloff:   &lightFalloff,
+		Illumination:   &illumination,
+		MinLightRGB:    &color.NRGBA{R: 76, G: 76, B: 76, A: 255},
+		MaxLightRGB:    &color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+	}
+	if g.debug {
+		g.defaultEnvironment.Floor = "grass_debug"
+	}
+
+	g.minLightRGB = &color.NRGBA{}
+	g.maxLightRGB = &color.NRGBA{}
+}
+
+// mapEnvironment returns the environment of the map over the demo environment, with the settings
+// changed from the menu kept over both
+func (g *Game) mapEnvironment(mapObj *model.Map) model.Environment {
+	return g.defaultEnvironment.Merge(mapObj.Environment()).Merge(g.environmentOverrides)
+}
+
+// applyEnvironment sets the render distance, lighting and textures of the current map environment,
+// which are set to the camera when it is created
+func (g *Game) applyEnvironment() {
+	g.environment = g.mapEnvironment(g.mapObj)
+	g.renderDistance = *g.environment.RenderDistance
+	g.lightFalloff = *g.environment.LightFalloff
+	g.globalIllumination = *g.environment.Illumination
+
+	// light colors are changed in place since the menu color pickers change them directly
+	*g.minLightRGB = *g.environment.MinLightRGB
+	*g.maxLightRGB = *g.environment.MaxLightRGB
+
+	if g.camera != nil {
+		g.setEnvironmentTextures()
+	}
+}
+
+// setEnvironmentTextures sets the floor and sky textures of the current map environment to the camera,
+// the same floor texture being used whether the floor is rendered textured or not
+func (g *Game) setEnvironmentTextures() {
+	g.camera.SetFloorTexture(g.getTexture(g.environment.Floor))
+	g.camera.SetSkyTexture(g.getTexture(g.environment.Sky))
+}
+
+// overrideRenderDistance changes the render distance from the menu, keeping it for maps loaded later
+func (g *Game) overrideRenderDistance(renderDistance float64) {
+	// sliders also report their initial value when the menu is created
+	if g.environmentOverrides.RenderDistance == nil && renderDistance == g.renderDistance {
+		return
+	}
+	g.environmentOverrides.RenderDistance = &renderDistance
+	g.setRenderDistance(renderDistance)
+}
+
+// overrideLightFalloff changes the light falloff from the menu, keeping it for maps loaded later
+func (g *Game) overrideLightFalloff(lightFalloff float64) {
+	if g.environmentOverrides.LightFalloff == nil && lightFalloff == g.lightFalloff {
+		return
+	}
+	g.environmentOverrides.LightFalloff = &lightFalloff
+	g.setLightFalloff(lightFalloff)
+}
+
+// overrideGlobalIllumination changes the global illumination from the menu, keeping it for maps loaded later
+func (g *Game) overrideGlobalIllumination(globalIllumination float64) {
+	if g.environmentOverrides.Illumination == nil && globalIllumination == g.globalIllumination {
+		return
+	}
+	g.environmentOverrides.Illumination = &globalIllumination
+	g.setGlobalIllumination(globalIllumination)
+}
+
+// overrideLightRGB applies the light colors after they are changed by the menu color pickers,
+// keeping the colors that differ from the map environment for maps loaded later
+func (g *Game) overrideLightRGB() {
+	if g.environmentOverrides.MinLightRGB != nil || *g.minLightRGB != *g.environment.MinLightRGB {
+		minLightRGB := *g.minLightRGB
+		g.environmentOverrides.MinLightRGB = &minLightRGB
+	}
+	if g.environmentOverrides.MaxLightRGB != nil || *g.maxLightRGB != *g.environment.MaxLightRGB {
+		maxLightRGB := *g.maxLightRGB
+		g.environmentOverrides.MaxLightRGB = &maxLightRGB
+	}
+	g.setLightRGB(g.minLightRGB, g.maxLightRGB)
+}
@@ -2,13 +2,16 @@ package game
 
 import (
 	"fmt"
+	"io/fs"
 	"log"
 	"math"
 	"math/rand"
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
+	"image"
 	"image/color"
 	_ "image/png"
 
@@ -20,6 +23,7 @@ import (
 	"github.com/harbdog/raycaster-go"
 	"github.com/harbdog/raycaster-go/geom"
 	"github.com/harbdog/raycaster-go/geom3d"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -31,6 +35,12 @@ const (
 	// distance to keep away from walls and obstacles to avoid clipping
 	// TODO: may want a smaller distance to test vs. sprites
 	clipDistance = 0.1
+
+	// maxUpdateDelta is the most seconds animations and timers are advanced by in a single update
+	maxUpdateDelta = 0.25
+
+	// hitIndicatorTime is the number of seconds the crosshairs hit indicator is shown after hitting a sprite
+	hitIndicatorTime = 0.5
 )
 
 // Game - This is the main type for your game.
@@ -41,6 +51,8 @@ type Game struct {
 	//--create slicer and declare slices--//
 	tex                *TextureHandler
 	initRenderFloorTex bool
+	// maxDecals is how many impact decals are kept on the walls before the oldest are removed
+	maxDecals int
 
 	// window resolution and scaling
 	screenWidth  int
@@ -78,9 +90,40 @@ type Game struct {
 	minLightRGB        *color.NRGBA
 	maxLightRGB        *color.NRGBA
 
+	// environment is the sky, floor, lighting and render distance of the current map, set from the map environment
+	// over the defaultEnvironment, with the environmentOverrides changed from the menu kept for every map loaded
+	environment          model.Environment
+	defaultEnvironment   model.Environment
+	environmentOverrides model.Environment
+
+	// resources are loaded from the resources path, or embedded if there is none
+	resourcesPath string
+	resources     fs.FS
+	// reload signals the game loop to reload changed resources while watching them
+	hotReload bool
+	reload    chan struct{}
+	// watchLevel watches the directory of the current level file after exiting to it (nil when not watching)
+	watchLevel func() error
+
 	//--array of levels, levels refer to "floors" of the world--//
-	mapObj       *model.Map
-	collisionMap []geom.Line
+	mapFile     string
+	mapGenerate model.GeneratorConfig
+	// levelFS is the file system of the current level file at levelPath, which changes when exiting to another level
+	levelFS   fs.FS
+	levelPath string
+	// mapGenerateTextures are the names of the wall textures picked from for generated maps
+	mapGenerateTextures []string
+	mapObj              *model.Map
+	// collision lines of the map edge
+	boundaryLines []geom.Line
+	// chunks are the loaded chunks near the chunkCenter of the player, with the chunkQueue of chunks to load next
+	chunks         map[image.Point]*mapChunk
+	chunkCenter    image.Point
+	chunksCentered bool
+	chunkQueue     []image.Point
+	// spriteChunks indexes the entities of the sprites by the chunk they are in, with spriteChunkOf the chunk of each
+	spriteChunks  map[image.Point]map[*model.Entity]struct{}
+	spriteChunkOf map[*model.Entity]image.Point
 
 	sprites     map[*model.Sprite]struct{}
 	projectiles map[*model.Projectile]struct{}
@@ -88,6 +131,18 @@ type Game struct {
 
 	mapWidth, mapHeight int
 
+	// spriteFactory creates the sprites of map sprite types from the archetype manifest
+	spriteFactory *model.SpriteFactory
+
+	// debrisSheet is the sprite sheet of debris effects, created when first needed
+	debrisSheet *ebiten.Image
+
+	// hazardTime counts the seconds until entities take damage from hazards again
+	hazardTime float64
+
+	// lastUpdate is when the game was last updated, to advance animations and timers by the time elapsed
+	lastUpdate time.Time
+
 	showSpriteBoxes bool
 	osType          osType
 	debug           bool
@@ -127,29 +182,42 @@ func NewGame() *Game {
 	g.setFullscreen(g.fullscreen)
 	g.setVsyncEnabled(g.vsync)
 
-	// load map
-	g.mapObj = model.NewMap()
+	g.resources = g.resourcesFS()
+	g.initEnvironment()
+
+	// load map, or generate one if a generator algorithm is configured
+	var err error
+	g.levelFS, g.levelPath, err = g.levelFile()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var mapFS fs.FS
+	g.mapObj, mapFS, err = g.loadMap()
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// load texture handler
-	g.tex = NewTextureHandler(g.mapObj, 32)
+	g.tex = NewTextureHandler(g.mapObj)
 	g.tex.renderFloorTex = g.initRenderFloorTex
+	g.tex.maxDecals = g.maxDecals
 
-	g.collisionMap = g.mapObj.GetCollisionLines(clipDistance)
+	g.updateCollisionMap()
 	worldMap := g.mapObj.Level(0)
 	g.mapWidth = len(worldMap)
 	g.mapHeight = len(worldMap[0])
 
 	// load content once when first run
-	g.loadContent()
+	g.loadContent(mapFS)
 
 	// create crosshairs and weapon
-	g.crosshairs = model.NewCrosshairs(1, 1, 2.0, g.tex.textures[16], 8, 8, 55, 57)
+	g.crosshairs = model.NewCrosshairs(1, 1, 2.0, g.getTexture("crosshairs_sheet"), 8, 8, 55, 57)
 
-	// init player model
-	angleDegrees := 60.0
-	g.player = model.NewPlayer(8.5, 3.5, geom.Radians(angleDegrees), 0)
+	// init player model at the map spawn point
+	spawn := g.mapObj.Spawn()
+	g.player = model.NewPlayer(spawn.X, spawn.Y, spawn.Angle, 0)
 	g.player.CollisionRadius = clipDistance
-	g.player.CollisionHeight = 0.5
+	g.player.CollisionHeight = model.PlayerHeight
 
 	// init the sprites
 	g.loadSprites()
@@ -164,33 +232,42 @@ func NewGame() *Game {
 	g.mouseMode = MouseModeLook
 	g.mouseX, g.mouseY = math.MinInt32, math.MinInt32
 
-	//--init camera and renderer--//
-	g.camera = raycaster.NewCamera(g.width, g.height, texWidth, g.mapObj, g.tex)
-	g.setRenderDistance(g.renderDistance)
-
-	g.camera.SetFloorTexture(getTextureFromFile("floor.png"))
-	g.camera.SetSkyTexture(getTextureFromFile("sky.png"))
+	// map lighting settings, set to the camera when it is created
+	g.applyEnvironment()
 
-	// initialize camera to player position
-	g.updatePlayerCamera(true)
-	g.setFovAngle(g.fovDegrees)
-	g.fovDepth = g.camera.FovDepth()
+	//--init camera and renderer--//
+	g.initCamera()
 
 	g.zoomFovDepth = 2.0
 
-	// set demo lighting settings
-	g.setLightFalloff(-200)
-	g.setGlobalIllumination(500)
-	minLightRGB := &color.NRGBA{R: 76, G: 76, B: 76, A: 255}
-	maxLightRGB := &color.NRGBA{R: 255, G: 255, B: 255, A: 255}
-	g.setLightRGB(minLightRGB, maxLightRGB)
-
 	// init menu system
 	g.menu = createMenu(g)
 
+	if g.hotReload {
+		err = g.watchResources()
+		if err != nil {
+			fmt.Printf("Unable to watch resources for changes: %v\n", err)
+		}
+	}
+
 	return g
 }
 
+// initCamera creates the camera for the current map, initialized to the player position and map environment
+func (g *Game) initCamera() {
+	g.camera = raycaster.NewCamera(g.width, g.height, texWidth, g.mapObj, g.tex)
+	g.setRenderDistance(g.renderDistance)
+	g.setLightFalloff(g.lightFalloff)
+	g.setGlobalIllumination(g.globalIllumination)
+	g.setLightRGB(g.minLightRGB, g.maxLightRGB)
+	g.setEnvironmentTextures()
+
+	// initialize camera to player position
+	g.updatePlayerCamera(true)
+	g.setFovAngle(g.fovDegrees)
+	g.fovDepth = g.camera.FovDepth()
+}
+
 func (g *Game) initConfig() {
 	viper.SetConfigName("demo-config")
 	viper.SetConfigType("json")
@@ -223,6 +300,25 @@ func (g *Game) initConfig() {
 	viper.SetDefault("screen.renderDistance", -1)
 	viper.SetDefault("screen.renderFloor", true)
 	viper.SetDefault("screen.fovDegrees", 68)
+	viper.SetDefault("screen.maxDecals", 64)
+	viper.SetDefault("resources.path", "")
+	viper.SetDefault("resources.hotReload", false)
+	viper.SetDefault("map.file", "")
+	viper.SetDefault("map.generate.algorithm", "")
+	viper.SetDefault("map.generate.seed", 0)
+	viper.SetDefault("map.generate.width", 32)
+	viper.SetDefault("map.generate.height", 32)
+	viper.SetDefault("map.generate.levels", 2)
+	viper.SetDefault("map.generate.textures", []string{"stone", "wood"})
+	viper.SetDefault("map.generate.sprites", 24)
+	viper.SetDefault("map.generate.spriteTypes", []string{"rock", "tree_09", "tree_10", "tree_14"})
+
+	// command line flags to generate a map (e.g. "go run main.go --generate=caves --seed=42")
+	pflag.String("generate", "", "generate a map with the given algorithm (rooms, caves or maze)")
+	pflag.Int64("seed", 0, "seed of the generated map, 0 for a random seed")
+	pflag.Parse()
+	viper.BindPFlag("map.generate.algorithm", pflag.Lookup("generate"))
+	viper.BindPFlag("map.generate.seed", pflag.Lookup("seed"))
 
 	if g.osType == osTypeBrowser {
 		viper.SetDefault("screen.width", 800)
@@ -254,6 +350,20 @@ func (g *Game) initConfig() {
 	g.opengl = viper.GetBool("screen.opengl")
 	g.renderDistance = viper.GetFloat64("screen.renderDistance")
 	g.initRenderFloorTex = viper.GetBool("screen.renderFloor")
+	g.maxDecals = viper.GetInt("screen.maxDecals")
+	g.resourcesPath = viper.GetString("resources.path")
+	g.hotReload = viper.GetBool("resources.hotReload")
+	g.mapFile = viper.GetString("map.file")
+	g.mapGenerate = model.GeneratorConfig{
+		Algorithm:   viper.GetString("map.generate.algorithm"),
+		Seed:        viper.GetInt64("map.generate.seed"),
+		Width:       viper.GetInt("map.generate.width"),
+		Height:      viper.GetInt("map.generate.height"),
+		NumLevels:   viper.GetInt("map.generate.levels"),
+		NumSprites:  viper.GetInt("map.generate.sprites"),
+		SpriteTypes: viper.GetStringSlice("map.generate.spriteTypes"),
+	}
+	g.mapGenerateTextures = viper.GetStringSlice("map.generate.textures")
 	g.showSpriteBoxes = viper.GetBool("showSpriteBoxes")
 	g.debug = viper.GetBool("debug")
 }
@@ -302,6 +412,8 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 // Update - Allows the game to run logic such as updating the world, gathering input, and playing audio.
 // Update is called every tick (1/60 [s] by default).
 func (g *Game) Update() error {
+	dt := g.updateDelta()
+
 	if g.osType == osTypeBrowser && ebiten.CursorMode() == ebiten.CursorModeVisible && !g.menu.active && !g.menu.closing {
 		// capture not working sometimes (https://developer.mozilla.org/en-US/docs/Web/API/Pointer_Lock_API#iframe_limitations):
 		//   sm_exec.js:349 pointerlockerror event is fired. 'sandbox="allow-pointer-lock"' might be required at an iframe.
@@ -315,6 +427,9 @@ func (g *Game) Update() error {
 		g.menu.closing = false
 	}
 
+	g.updateReload()
+	g.updateChunks()
+
 	// handle input (when paused making sure only to allow input for closing menu so it can be unpaused)
 	g.handleInput()
 
@@ -322,10 +437,17 @@ func (g *Game) Update() error {
 		// Perform logical updates
 		w := g.player.Weapon
 		if w != nil {
-			w.Update()
+			w.Update(dt)
+		}
+		if g.crosshairs != nil {
+			g.crosshairs.Update(dt)
 		}
-		g.updateProjectiles()
-		g.updateSprites()
+		g.tex.Update(dt)
+		g.updateDoors(dt)
+		g.updateHazards(dt)
+		g.updateProjectiles(dt)
+		g.updateSprites(dt)
+		g.updateTriggers(dt)
 
 		// handle player camera movement
 		g.updatePlayerCamera(false)
@@ -455,7 +577,6 @@ func (g *Game) Draw(screen *ebiten.Image) {
 
 		if g.crosshairs.IsHitIndicatorActive() {
 			screen.DrawImage(g.crosshairs.HitIndicator.Texture(), op)
-			g.crosshairs.Update()
 		}
 	}
 
@@ -547,13 +668,9 @@ func (g *Game) setFovAngle(fovDegrees float64) {
 
 // Move player by move speed in the forward/backward direction
 func (g *Game) Move(mSpeed float64) {
+	mSpeed *= g.hazardAt(g.player.Entity).Speed
 	moveLine := geom.LineFromAngle(g.player.Position.X, g.player.Position.Y, g.player.Angle, mSpeed)
-
-	newPos, _, _ := g.getValidMove(g.player.Entity, moveLine.X2, moveLine.Y2, g.player.PositionZ, true)
-	if !newPos.Equals(g.player.Pos()) {
-		g.player.Position = newPos
-		g.player.Moved = true
-	}
+	g.movePlayer(moveLine)
 }
 
 // Move player by strafe speed in the left/right direction
@@ -562,9 +679,19 @@ func (g *Game) Strafe(sSpeed float64) {
 	if sSpeed < 0 {
 		strafeAngle = -strafeAngle
 	}
-	strafeLine := geom.LineFromAngle(g.player.Position.X, g.player.Position.Y, g.player.Angle-strafeAngle, math.Abs(sSpeed))
+	sSpeed = math.Abs(sSpeed) * g.hazardAt(g.player.Entity).Speed
+	strafeLine := geom.LineFromAngle(g.player.Position.X, g.player.Position.Y, g.player.Angle-strafeAngle, sSpeed)
+	g.movePlayer(strafeLine)
+}
+
+// movePlayer moves the player along the move line, or pushes it along the line on slippery floors
+// to slide by the friction of the floor
+func (g *Game) movePlayer(moveLine geom.Line) {
+	if g.pushSlide(g.player.Entity, moveLine) {
+		return
+	}
 
-	newPos, _, _ := g.getValidMove(g.player.Entity, strafeLine.X2, strafeLine.Y2, g.player.PositionZ, true)
+	newPos, _, _ := g.getValidMove(g.player.Entity, moveLine.X2, moveLine.Y2, g.player.PositionZ, true)
 	if !newPos.Equals(g.player.Pos()) {
 		g.player.Position = newPos
 		g.player.Moved = true
@@ -666,17 +793,23 @@ func (g *Game) updatePlayerCamera(forceUpdate bool) {
 	g.player.Moved = false
 
 	g.camera.SetPosition(g.player.Position.Copy())
+	g.tex.viewX, g.tex.viewY = g.player.Position.X, g.player.Position.Y
 	g.camera.SetPositionZ(g.player.CameraZ)
 	g.camera.SetHeadingAngle(g.player.Angle)
 	g.camera.SetPitchAngle(g.player.Pitch)
 }
 
-func (g *Game) updateProjectiles() {
+func (g *Game) updateProjectiles(dt float64) {
 	// Testing animated projectile movement
 	for p := range g.projectiles {
+		if !g.isActiveAt(p.Position) {
+			// projectiles flying away from the player are gone before reaching walls that are not loaded
+			g.deleteProjectile(p)
+			continue
+		}
 		if p.Velocity != 0 {
 
-			trajectory := geom3d.Line3dFromAngle(p.Position.X, p.Position.Y, p.PositionZ, p.Angle, p.Pitch, p.Velocity)
+			trajectory := geom3d.Line3dFromAngle(p.Position.X, p.Position.Y, p.PositionZ, p.Angle, p.Pitch, p.Velocity*dt)
 
 			xCheck := trajectory.X2
 			yCheck := trajectory.Y2
@@ -687,6 +820,11 @@ func (g *Game) updateProjectiles() {
 				// for testing purposes, projectiles instantly get deleted when collision occurs
 				g.deleteProjectile(p)
 
+				// wear down or leave a decal on the wall the projectile hit
+				if isCollision && len(collisions) == 0 {
+					g.hitWall(p)
+				}
+
 				// make a sprite/wall getting hit by projectile cause some visual effect
 				if p.ImpactEffect.Sprite != nil {
 					if len(collisions) >= 1 {
@@ -705,7 +843,8 @@ func (g *Game) updateProjectiles() {
 						println("ouch!")
 					} else {
 						// show crosshair hit effect
-						g.crosshairs.ActivateHitIndicator(30)
+						g.crosshairs.ActivateHitIndicator(hitIndicatorTime)
+						g.hurtSprite(collisionEntity.entity)
 					}
 				}
 			} else {
@@ -713,41 +852,73 @@ func (g *Game) updateProjectiles() {
 				p.PositionZ = zCheck
 			}
 		}
-		p.Update(g.player.Position)
+		p.Update(g.player.Position, dt)
 	}
 
 	// Testing animated effects (explosions)
 	for e := range g.effects {
-		e.Update(g.player.Position)
+		e.Update(g.player.Position, dt)
 		if e.LoopCounter() >= e.LoopCount {
 			g.deleteEffect(e)
 		}
 	}
 }
 
-func (g *Game) updateSprites() {
+func (g *Game) updateSprites(dt float64) {
 	// Testing animated sprite movement
 	for s := range g.sprites {
-		if s.Velocity != 0 {
-			vLine := geom.LineFromAngle(s.Position.X, s.Position.Y, s.Angle, s.Velocity)
-
-			xCheck := vLine.X2
-			yCheck := vLine.Y2
-			zCheck := s.PositionZ
-
-			newPos, isCollision, _ := g.getValidMove(s.Entity, xCheck, yCheck, zCheck, false)
-			if isCollision {
-				// for testing purposes, letting the sample sprite ping pong off walls in somewhat random direction
-				s.Angle = randFloat(-math.Pi, math.Pi)
-				s.Velocity = randFloat(0.01, 0.03)
-			} else {
-				s.Position = newPos
+		if !g.isActiveAt(s.Position) {
+			// sprites away from the player are left as they are until the player comes near
+			continue
+		}
+		if s.Velocity != 0 && !isCreatureBusy(s) {
+			vLine := geom.LineFromAngle(s.Position.X, s.Position.Y, s.Angle, s.Velocity*g.hazardAt(s.Entity).Speed)
+
+			if !g.pushSlide(s.Entity, vLine) {
+				xCheck := vLine.X2
+				yCheck := vLine.Y2
+				zCheck := s.PositionZ
+
+				newPos, isCollision, _ := g.getValidMove(s.Entity, xCheck, yCheck, zCheck, false)
+				if isCollision {
+					// for testing purposes, letting the sample sprite ping pong off walls in somewhat random direction
+					s.Angle = randFloat(-math.Pi, math.Pi)
+					s.Velocity = randFloat(0.01, 0.03)
+				} else {
+					s.Position = newPos
+					g.indexSprite(s.Entity)
+				}
 			}
 		}
-		s.Update(g.player.Position)
+
+		// sprites keep sliding along on slippery floors, turning away when sliding into something
+		moved, isCollision := g.slideEntity(s.Entity, false)
+		if isCollision {
+			s.Slide = geom.Vector2{}
+			s.Angle = randFloat(-math.Pi, math.Pi)
+		}
+		if moved {
+			g.indexSprite(s.Entity)
+		}
+		g.updateCreatureClip(s)
+		s.Update(g.player.Position, dt)
 	}
 }
 
+// updateDelta returns the number of seconds since the last update to advance animations and timers by,
+// so they keep the same speed whatever the TPS and when updates fall behind (such as in slow browsers)
+func (g *Game) updateDelta() float64 {
+	now := time.Now()
+	if g.lastUpdate.IsZero() {
+		g.lastUpdate = now
+	}
+	dt := now.Sub(g.lastUpdate).Seconds()
+	g.lastUpdate = now
+
+	// avoid jumping ahead after the game was stalled, such as while loading a level or in a hidden browser tab
+	return math.Min(dt, maxUpdateDelta)
+}
+
 func randFloat(min, max float64) float64 {
 	return min + rand.Float64()*(max-min)
 }
@@ -0,0 +1,97 @@
+package game
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/harbdog/raycaster-go-demo/game/model"
+	"github.com/harbdog/raycaster-go/geom"
+)
+
+const (
+	// hazardDamageInterval is the number of seconds between entities taking damage from hazards
+	hazardDamageInterval = 0.5
+
+	// minSlide is the slide distance per tick below which an entity stops sliding
+	minSlide = 0.001
+)
+
+// hazardAt returns the effect of the floor hazard the entity is standing on,
+// entities above the floor are not affected by hazards
+func (g *Game) hazardAt(entity *model.Entity) model.HazardEffect {
+	if entity.PositionZ > 0 {
+		return model.HazardNone.Effect()
+	}
+	return g.mapObj.HazardAt(int(entity.Position.X), int(entity.Position.Y)).Effect()
+}
+
+// updateHazards slides the player along on slippery floors, and periodically damages the player
+// and sprites standing on damaging floors
+func (g *Game) updateHazards(dt float64) {
+	if moved, _ := g.slideEntity(g.player.Entity, true); moved {
+		g.player.Moved = true
+	}
+
+	g.hazardTime += dt
+	if g.hazardTime < hazardDamageInterval {
+		return
+	}
+	g.hazardTime -= hazardDamageInterval
+
+	for sprite := range g.sprites {
+		if !g.isActiveAt(sprite.Position) || sprite.IsDead() {
+			// sprites already dead are playing their die clip
+			continue
+		}
+		sprite.Damage(g.hazardAt(sprite.Entity).Damage * hazardDamageInterval)
+		if sprite.IsDead() {
+			g.killSprite(sprite)
+		}
+	}
+
+	if damage := g.hazardAt(g.player.Entity).Damage * hazardDamageInterval; damage > 0 {
+		g.player.Damage(damage)
+		if g.player.IsDead() {
+			g.player.Health = g.player.MaxHealth
+			if err := g.restartLevel(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}
+}
+
+// pushSlide pushes the slide of the entity along the move line by the friction of the floor,
+// returning false if the floor is not slippery so the entity can move along the line itself
+func (g *Game) pushSlide(entity *model.Entity, moveLine geom.Line) bool {
+	friction := g.hazardAt(entity).Friction
+	if friction >= 1 {
+		return false
+	}
+	entity.Slide.X += (moveLine.X2 - moveLine.X1) * friction
+	entity.Slide.Y += (moveLine.Y2 - moveLine.Y1) * friction
+	return true
+}
+
+// slideEntity moves the entity along with its slide, slowing down by the friction of the floor,
+// returning whether the entity moved and whether it slid into a collision
+func (g *Game) slideEntity(entity *model.Entity, checkAlternate bool) (bool, bool) {
+	slide := &entity.Slide
+	if slide.X == 0 && slide.Y == 0 {
+		return false, false
+	}
+
+	moved := false
+	newPos, isCollision, _ := g.getValidMove(entity, entity.Position.X+slide.X, entity.Position.Y+slide.Y, entity.PositionZ, checkAlternate)
+	if !newPos.Equals(entity.Pos()) {
+		entity.Position = newPos
+		moved = true
+	}
+
+	friction := g.hazardAt(entity).Friction
+	slide.X *= 1 - friction
+	slide.Y *= 1 - friction
+	if math.Hypot(slide.X, slide.Y) < minSlide {
+		*slide = geom.Vector2{}
+	}
+	return moved, isCollision
+}
@@ -178,6 +178,11 @@ func (g *Game) handleInput() {
 		g.player.SelectWeapon(-1)
 	}
 
+	if inpututil.IsKeyJustPressed(ebiten.KeyE) {
+		// open/close door
+		g.interact()
+	}
+
 	if ebiten.IsKeyPressed(ebiten.KeyA) || ebiten.IsKeyPressed(ebiten.KeyLeft) {
 		rotLeft = true
 	}
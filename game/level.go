@@ -0,0 +1,122 @@
+package game
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+
+	"github.com/harbdog/raycaster-go-demo/game/model"
+	"github.com/harbdog/raycaster-go/geom"
+)
+
+// endLevel restarts the level from the player spawn, generating a new map if maps are generated
+func (g *Game) endLevel() error {
+	fmt.Printf("Level complete\n")
+	return g.restartLevel()
+}
+
+// restartLevel loads the current level again, placing the player at the spawn,
+// keeping the current map if the level file cannot be loaded
+func (g *Game) restartLevel() error {
+	mapObj, mapFS, err := g.loadMap()
+	if err != nil {
+		return err
+	}
+	return g.loadLevel(mapObj, mapFS, false)
+}
+
+// exitLevel leaves the current map for the level file at the path relative to the current level file,
+// keeping the current map if the level file cannot be loaded
+func (g *Game) exitLevel(level string) error {
+	if g.levelFS == nil {
+		return fmt.Errorf("unable to exit to level %s from a generated map", level)
+	}
+
+	levelPath := path.Join(path.Dir(g.levelPath), level)
+	mapObj, err := model.LoadMap(g.levelFS, levelPath)
+	if err != nil {
+		return err
+	}
+	if err := g.loadLevel(mapObj, g.levelFS, false); err != nil {
+		return err
+	}
+
+	fmt.Printf("Entering level %s\n", levelPath)
+	g.levelPath = levelPath
+
+	if g.watchLevel != nil {
+		// the level exited to may be in a directory not watched yet
+		if err := g.watchLevel(); err != nil {
+			fmt.Printf("Unable to watch level %s for changes: %v\n", levelPath, err)
+		}
+	}
+	return nil
+}
+
+// loadLevel replaces the current map with the map loaded from the map file system (nil for generated maps),
+// along with the textures it declares. The sprites, projectiles, effects and collision lines of the
+// current map are replaced, while the player carries over its weapons and stance.
+func (g *Game) loadLevel(mapObj *model.Map, mapFS fs.FS, keepPlayer bool) error {
+	tex := NewTextureHandler(mapObj)
+	tex.renderFloorTex = g.tex.renderFloorTex
+	tex.maxDecals = g.tex.maxDecals
+	if err := g.loadTextures(tex, mapFS); err != nil {
+		return err
+	}
+	spriteFactory, err := g.loadArchetypes(tex)
+	if err != nil {
+		return err
+	}
+	// create the sprites before changing anything, so a map with an unknown sprite type is rejected
+	sprites, err := g.newMapSprites(mapObj, spriteFactory)
+	if err != nil {
+		return err
+	}
+
+	// replace the textures in place since they are shared with the camera
+	*g.tex = *tex
+	g.tex.mapObj = g.mapObj
+	g.spriteFactory = spriteFactory
+
+	g.setMap(mapObj, sprites, keepPlayer)
+	return nil
+}
+
+// setMap replaces the current map in place, since it is shared with the camera and texture handler,
+// and replaces the sprites with the sprites of the map.
+// The player is moved to the spawn of the map, unless keeping its position and it is still valid in the map.
+func (g *Game) setMap(mapObj *model.Map, sprites map[*model.Sprite]struct{}, keepPlayer bool) {
+	*g.mapObj = *mapObj
+	g.updateCollisionMap()
+	worldMap := g.mapObj.Level(0)
+	g.mapWidth = len(worldMap)
+	g.mapHeight = len(worldMap[0])
+
+	if !keepPlayer || !g.isValidPlayerPosition() {
+		spawn := g.mapObj.Spawn()
+		g.player.Position.X, g.player.Position.Y = spawn.X, spawn.Y
+		g.player.PositionZ = 0
+		g.player.Angle = spawn.Angle
+		g.player.Slide = geom.Vector2{}
+	}
+
+	g.projectiles = make(map[*model.Projectile]struct{}, 1024)
+	g.effects = make(map[*model.Effect]struct{}, 1024)
+	g.setSprites(sprites)
+	g.applyEnvironment()
+
+	// recreate the camera for the size and number of levels of the map
+	g.initCamera()
+}
+
+// isValidPlayerPosition returns true if the player is within the map and not inside a wall
+func (g *Game) isValidPlayerPosition() bool {
+	x, y := math.Floor(g.player.Position.X), math.Floor(g.player.Position.Y)
+	if x < 0 || y < 0 || x >= float64(g.mapWidth) || y >= float64(g.mapHeight) {
+		return false
+	}
+
+	minZ, maxZ := zEntityMinMax(g.player.PositionZ, g.player.Entity)
+	return !g.isWallAt(int(x), int(y), minZ, maxZ)
+}
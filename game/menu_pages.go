@@ -245,7 +245,7 @@ func renderPage(m *DemoMenu) *page {
 		widget.SliderOpts.TrackOffset(5),
 		widget.SliderOpts.ChangedHandler(func(args *widget.SliderChangedEventArgs) {
 			distanceValueText.Label = fmt.Sprintf("%d", args.Current)
-			m.game.setRenderDistance(float64(args.Current))
+			m.game.overrideRenderDistance(float64(args.Current))
 		}),
 	)
 	distanceSlider.Current = int(m.game.renderDistance)
@@ -304,7 +304,7 @@ func lightingPage(m *DemoMenu) *page {
 		widget.SliderOpts.TrackOffset(5),
 		widget.SliderOpts.ChangedHandler(func(args *widget.SliderChangedEventArgs) {
 			falloffValueText.Label = fmt.Sprintf("%d", args.Current)
-			m.game.setLightFalloff(float64(args.Current))
+			m.game.overrideLightFalloff(float64(args.Current))
 		}),
 	)
 	falloffSlider.Current = int(m.game.lightFalloff)
@@ -341,7 +341,7 @@ func lightingPage(m *DemoMenu) *page {
 		widget.SliderOpts.TrackOffset(5),
 		widget.SliderOpts.ChangedHandler(func(args *widget.SliderChangedEventArgs) {
 			globalValueText.Label = fmt.Sprintf("%d", args.Current)
-			m.game.setGlobalIllumination(float64(args.Current))
+			m.game.overrideGlobalIllumination(float64(args.Current))
 		}),
 	)
 	globalSlider.Current = int(m.game.globalIllumination)
@@ -357,13 +357,13 @@ func lightingPage(m *DemoMenu) *page {
 
 	// min lighting RGB selection
 	pickerMinRGB := m.newColorPickerRGB("Min Light", m.game.minLightRGB, func(args *widget.SliderChangedEventArgs) {
-		m.game.setLightRGB(m.game.minLightRGB, m.game.maxLightRGB)
+		m.game.overrideLightRGB()
 	})
 	c.AddChild(pickerMinRGB)
 
 	// max lighting RGB selection
 	pickerMaxRGB := m.newColorPickerRGB("Max Light", m.game.maxLightRGB, func(args *widget.SliderChangedEventArgs) {
-		m.game.setLightRGB(m.game.minLightRGB, m.game.maxLightRGB)
+		m.game.overrideLightRGB()
 	})
 	c.AddChild(pickerMaxRGB)
 
@@ -3,24 +3,32 @@ package game
 import (
 	"image"
 	"image/color"
+	"image/draw"
 	"sort"
 
 	"github.com/harbdog/raycaster-go-demo/game/model"
 )
 
+// miniMap draws the area of the map covered by the active chunks around the player
 func (g *Game) miniMap() *image.RGBA {
-	m := image.NewRGBA(image.Rect(0, 0, g.mapWidth, g.mapHeight))
+	active := g.activeChunks()
+	area := image.Rect(active.Min.X*chunkSize, active.Min.Y*chunkSize, active.Max.X*chunkSize, active.Max.Y*chunkSize)
+	area = area.Intersect(image.Rect(0, 0, g.mapWidth, g.mapHeight))
+	m := image.NewRGBA(area)
 
-	// wall/world positions
-	worldMap := g.mapObj.Level(0)
-	for x, row := range worldMap {
-		for y := range row {
-			c := g.getMapColor(x, y)
-			if c.A == 255 {
-				c.A = 142
-			}
-			m.Set(x, y, c)
+	// walls of the loaded chunks, chunks that are still queued to load are left blank
+	for _, chunk := range g.chunks {
+		draw.Draw(m, chunk.area, chunk.miniMap, chunk.area.Min, draw.Src)
+	}
+
+	// door positions, fading as they open
+	for _, door := range g.mapObj.Doors() {
+		c := color.RGBA{139, 90, 43, 196}
+		if door.Locked {
+			c = color.RGBA{160, 40, 30, 196}
 		}
+		c.A = uint8(float64(c.A) * (1 - door.OpenAmount()/2))
+		m.Set(door.X, door.Y, c)
 	}
 
 	// sprite positions, sort by color to avoid random color getting chosen as last when using map keys
@@ -65,9 +73,57 @@ func (g *Game) miniMap() *image.RGBA {
 	return m
 }
 
-func (g *Game) getMapColor(x, y int) color.RGBA {
-	worldMap := g.mapObj.Level(0)
-	switch worldMap[x][y] {
+// miniMapArea draws the walls of the area of the map, using the lowest wall of each position across all levels
+func (g *Game) miniMapArea(area image.Rectangle) *image.RGBA {
+	m := image.NewRGBA(area)
+
+	numLevels := g.mapObj.NumLevels()
+	for x := area.Min.X; x < area.Max.X; x++ {
+		for y := area.Min.Y; y < area.Max.Y; y++ {
+			wallLevel, wallValue := 0, 0
+			for levelNum := 0; levelNum < numLevels; levelNum++ {
+				if value := g.mapObj.Level(levelNum)[x][y]; value > 0 {
+					wallLevel, wallValue = levelNum, value
+					break
+				}
+			}
+
+			c := getMapColor(wallValue)
+			if hazard := g.mapObj.HazardAt(x, y); wallValue == 0 && hazard != model.HazardNone {
+				c = getHazardColor(hazard)
+			}
+			if c.A == 255 {
+				c.A = 142
+			}
+			if wallLevel > 0 {
+				// walls that do not reach down to the ground are shown faded
+				c.A /= 2
+			}
+			m.Set(x, y, c)
+		}
+	}
+
+	return m
+}
+
+// getHazardColor returns the minimap color of open positions with a floor hazard
+func getHazardColor(hazard model.Hazard) color.RGBA {
+	switch hazard {
+	case model.HazardLava:
+		return color.RGBA{207, 64, 16, 196}
+	case model.HazardWater:
+		return color.RGBA{32, 84, 160, 196}
+	case model.HazardMud:
+		return color.RGBA{84, 60, 30, 196}
+	case model.HazardIce:
+		return color.RGBA{168, 212, 232, 196}
+	default:
+		return color.RGBA{43, 30, 24, 255}
+	}
+}
+
+func getMapColor(wallValue int) color.RGBA {
+	switch wallValue {
 	case 0:
 		return color.RGBA{43, 30, 24, 255}
 	case 1:
@@ -0,0 +1,170 @@
+package model
+
+// names of the animation clips creatures commonly have
+const (
+	ClipIdle   = "idle"
+	ClipWalk   = "walk"
+	ClipAttack = "attack"
+	ClipHurt   = "hurt"
+	ClipDie    = "die"
+)
+
+// AnimationClip is a named range of frames of a sprite sheet. For sprites with a texture facing map
+// the frames are the columns of the facing row, otherwise they are the frame indexes of the whole sheet.
+type AnimationClip struct {
+	// First and Last are the first and last frame of the clip
+	First, Last int
+	// Rate is the number of frames shown per second, 0 to use the frame durations or animation rate of the sprite
+	Rate float64
+	// Loop plays the clip again from its first frame when it ends, otherwise it stops at its last frame
+	Loop bool
+	// Reverse plays the frames from last to first, and PingPong plays them forward then back
+	Reverse, PingPong bool
+	// Next is the clip to play when the clip ends (empty to stay on the clip)
+	Next string
+}
+
+// AddAnimationClip adds a named clip the sprite can play, the first clip added is played right away
+func (s *Sprite) AddAnimationClip(name string, clip AnimationClip) {
+	if s.clips == nil {
+		s.clips = make(map[string]*AnimationClip)
+	}
+	s.clips[name] = &clip
+
+	if s.clipName == "" {
+		s.startClip(name)
+	}
+}
+
+// SetAnimationTransitions limits the clips that can be played after the named clip to the given clips,
+// no clips making it a final clip (such as a die clip). Clips without transitions can change to any clip.
+func (s *Sprite) SetAnimationTransitions(from string, to ...string) {
+	if s.transitions == nil {
+		s.transitions = make(map[string][]string)
+	}
+	s.transitions[from] = to
+}
+
+// HasAnimationClip returns true if the sprite has a clip with the name
+func (s *Sprite) HasAnimationClip(name string) bool {
+	_, ok := s.clips[name]
+	return ok
+}
+
+// AnimationClip returns the name of the clip being played (empty if the sprite has no clips)
+func (s *Sprite) AnimationClip() string {
+	return s.clipName
+}
+
+// IsAnimationFinished returns true if the clip being played stopped at its last frame
+func (s *Sprite) IsAnimationFinished() bool {
+	return s.clipFinished
+}
+
+// PlayAnimation changes to the named clip from its first frame, returning false if the sprite has no
+// such clip or the clip being played cannot change to it. Playing the clip already being played
+// keeps it going unless it has finished.
+func (s *Sprite) PlayAnimation(name string) bool {
+	if _, ok := s.clips[name]; !ok {
+		return false
+	}
+	if name == s.clipName && !s.clipFinished {
+		return true
+	}
+	if !s.canTransition(s.clipName, name) {
+		return false
+	}
+
+	s.startClip(name)
+	return true
+}
+
+// canTransition returns true if the transitions of the clip allow changing to the other clip
+func (s *Sprite) canTransition(from, to string) bool {
+	allowed, ok := s.transitions[from]
+	if !ok {
+		return true
+	}
+	for _, name := range allowed {
+		if name == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Sprite) startClip(name string) {
+	s.clipName = name
+	s.clipFrame = 0
+	s.clipFinished = false
+	s.animTime = 0
+}
+
+// steps returns the number of frames shown playing the clip through once
+func (c *AnimationClip) steps() int {
+	n := c.Last - c.First + 1
+	if c.PingPong && n > 1 {
+		return 2*n - 2
+	}
+	return n
+}
+
+// frame returns the frame of the sprite sheet row shown at the step of the clip
+func (c *AnimationClip) frame(step int, reversed bool) int {
+	n := c.Last - c.First + 1
+	if step >= n {
+		// coming back on a ping-pong clip
+		step = 2*n - 2 - step
+	}
+	if c.Reverse != reversed {
+		return c.Last - step
+	}
+	return c.First + step
+}
+
+// updateClip advances the clip being played by the number of seconds elapsed, turning to the facing row
+// for the camera position. When the clip ends it calls OnAnimationFinished and changes to the next clip
+// of the clip if it has one.
+func (s *Sprite) updateClip(clip *AnimationClip, facingRow int, dt float64) {
+	s.texNum = facingRow*s.columns + clip.frame(s.clipFrame, s.animReversed)
+
+	ended := false
+	s.animTime += dt
+	for !s.clipFinished {
+		duration := s.frameDuration(s.texNum)
+		if clip.Rate > 0 {
+			duration = 1 / clip.Rate
+		}
+		if duration <= 0 || s.animTime < duration {
+			break
+		}
+		s.animTime -= duration
+
+		s.clipFrame++
+		if s.clipFrame >= clip.steps() {
+			ended = true
+			if clip.Loop {
+				s.clipFrame = 0
+				s.loopCounter++
+			} else {
+				s.clipFrame = clip.steps() - 1
+				s.clipFinished = true
+			}
+		}
+		s.texNum = facingRow*s.columns + clip.frame(s.clipFrame, s.animReversed)
+	}
+	if s.clipFinished {
+		s.animTime = 0
+	}
+
+	if ended {
+		name := s.clipName
+		if s.OnAnimationFinished != nil {
+			s.OnAnimationFinished(s, name)
+		}
+		// the callback may have played another clip already
+		if s.clipName == name && clip.Next != "" {
+			s.PlayAnimation(clip.Next)
+		}
+	}
+}
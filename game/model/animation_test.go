@@ -0,0 +1,141 @@
+package model
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/harbdog/raycaster-go"
+)
+
+// newClipSprite creates a sprite with a sheet of 4 frames in a row with idle, walk, attack and die clips,
+// the attack clip moving on to the idle clip and the die clip being final
+func newClipSprite() *Sprite {
+	s := NewAnimatedSprite(1, 1, 1, 0, ebiten.NewImage(64, 16), color.RGBA{}, 4, 1, raycaster.AnchorBottom, 0, 0)
+	s.AddAnimationClip(ClipIdle, AnimationClip{First: 0, Last: 1, Rate: 2, Loop: true})
+	s.AddAnimationClip(ClipWalk, AnimationClip{First: 0, Last: 3, Rate: 4, Loop: true})
+	s.AddAnimationClip(ClipAttack, AnimationClip{First: 2, Last: 3, Rate: 4, Next: ClipIdle})
+	s.AddAnimationClip(ClipDie, AnimationClip{First: 1, Last: 3, Rate: 4})
+	s.SetAnimationTransitions(ClipDie)
+	return s
+}
+
+func TestAnimationClipFrame(t *testing.T) {
+	tests := []struct {
+		name       string
+		clip       AnimationClip
+		reversed   bool
+		wantFrames []int
+	}{
+		{"forward", AnimationClip{First: 1, Last: 3}, false, []int{1, 2, 3}},
+		{"reverse", AnimationClip{First: 1, Last: 3, Reverse: true}, false, []int{3, 2, 1}},
+		{"reversed sprite", AnimationClip{First: 1, Last: 3}, true, []int{3, 2, 1}},
+		{"reverse of reversed sprite", AnimationClip{First: 1, Last: 3, Reverse: true}, true, []int{1, 2, 3}},
+		{"ping-pong", AnimationClip{First: 0, Last: 3, PingPong: true}, false, []int{0, 1, 2, 3, 2, 1}},
+		{"single frame ping-pong", AnimationClip{First: 2, Last: 2, PingPong: true}, false, []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames := make([]int, 0, tt.clip.steps())
+			for step := 0; step < tt.clip.steps(); step++ {
+				frames = append(frames, tt.clip.frame(step, tt.reversed))
+			}
+			if !reflect.DeepEqual(frames, tt.wantFrames) {
+				t.Errorf("frames = %v, want %v", frames, tt.wantFrames)
+			}
+		})
+	}
+}
+
+func TestPlayAnimation(t *testing.T) {
+	tests := []struct {
+		name     string
+		play     []string
+		wantPlay []bool
+		wantClip string
+	}{
+		{"first clip added", nil, nil, ClipIdle},
+		{"change clip", []string{ClipWalk}, []bool{true}, ClipWalk},
+		{"unknown clip", []string{"fly"}, []bool{false}, ClipIdle},
+		{"same clip", []string{ClipIdle}, []bool{true}, ClipIdle},
+		{"into final clip", []string{ClipDie}, []bool{true}, ClipDie},
+		{"out of final clip", []string{ClipDie, ClipIdle, ClipWalk}, []bool{true, false, false}, ClipDie},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newClipSprite()
+			for i, name := range tt.play {
+				if got := s.PlayAnimation(name); got != tt.wantPlay[i] {
+					t.Errorf("PlayAnimation(%q) = %v, want %v", name, got, tt.wantPlay[i])
+				}
+			}
+			if got := s.AnimationClip(); got != tt.wantClip {
+				t.Errorf("AnimationClip() = %q, want %q", got, tt.wantClip)
+			}
+		})
+	}
+}
+
+func TestUpdateClip(t *testing.T) {
+	tests := []struct {
+		name         string
+		clip         string
+		updates      []float64
+		wantTexNum   int
+		wantClip     string
+		wantFinished bool
+		wantCalls    []string
+	}{
+		// clips end once their last frame has been shown for its duration
+		{"first frame", ClipWalk, []float64{0.1}, 0, ClipWalk, false, nil},
+		{"by elapsed time", ClipWalk, []float64{0.25, 0.25}, 2, ClipWalk, false, nil},
+		{"several frames in one update", ClipWalk, []float64{0.5}, 2, ClipWalk, false, nil},
+		{"looping", ClipWalk, []float64{1.25}, 1, ClipWalk, false, []string{ClipWalk}},
+		{"stops at last frame", ClipDie, []float64{0.75}, 3, ClipDie, true, []string{ClipDie}},
+		{"finished once", ClipDie, []float64{0.75, 0.5, 0.5}, 3, ClipDie, true, []string{ClipDie}},
+		{"next clip", ClipAttack, []float64{0.5, 0.1}, 0, ClipIdle, false, []string{ClipAttack}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newClipSprite()
+			var calls []string
+			s.OnAnimationFinished = func(_ *Sprite, clip string) {
+				calls = append(calls, clip)
+			}
+
+			s.PlayAnimation(tt.clip)
+			for _, dt := range tt.updates {
+				s.Update(nil, dt)
+			}
+			if s.texNum != tt.wantTexNum {
+				t.Errorf("texNum = %d, want %d", s.texNum, tt.wantTexNum)
+			}
+			if s.AnimationClip() != tt.wantClip || s.IsAnimationFinished() != tt.wantFinished {
+				t.Errorf("AnimationClip() = %q finished %v, want %q finished %v",
+					s.AnimationClip(), s.IsAnimationFinished(), tt.wantClip, tt.wantFinished)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("OnAnimationFinished calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestOnAnimationFinishedPlaysClip(t *testing.T) {
+	s := newClipSprite()
+	s.OnAnimationFinished = func(s *Sprite, clip string) {
+		if clip == ClipAttack {
+			s.PlayAnimation(ClipWalk)
+		}
+	}
+
+	s.PlayAnimation(ClipAttack)
+	s.Update(nil, 0.5)
+	if s.AnimationClip() != ClipWalk {
+		t.Errorf("AnimationClip() = %q, want the walk clip played by the callback over the next clip", s.AnimationClip())
+	}
+}
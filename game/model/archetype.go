@@ -0,0 +1,182 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+
+	"github.com/harbdog/raycaster-go"
+)
+
+// ArchetypeManifest lists the sprite archetypes that can be placed on maps by name
+// (see "Sprite archetypes" in README.md)
+type ArchetypeManifest struct {
+	Archetypes []Archetype `json:"archetypes"`
+}
+
+// Archetype declares how to create the sprites of a sprite type
+type Archetype struct {
+	Name string `json:"name"`
+	// Texture is the name of the sprite or sheet texture in the texture manifest
+	Texture string `json:"texture"`
+	// Columns and Rows are the grid of frames of a sheet texture without sprite sheet data
+	Columns int `json:"columns"`
+	Rows    int `json:"rows"`
+	// Scale is the default scale of the sprites (1 if left out)
+	Scale float64 `json:"scale"`
+	// Anchor is the part of the sprite at its height: "bottom" (the default), "center" or "top"
+	Anchor string `json:"anchor"`
+	// CollisionRadius and CollisionHeight are the collision size in pixels of a frame (0 for no collision)
+	CollisionRadius float64 `json:"collisionRadius"`
+	CollisionHeight float64 `json:"collisionHeight"`
+	// MapColor is the RGBA color of the sprite on the minimap
+	MapColor [4]uint8 `json:"mapColor"`
+	// Rate is the number of animation frames per second (0 to not animate other than by sprite sheet data)
+	Rate float64 `json:"rate"`
+	// Reversed plays the frames of the sheet from last to first
+	Reversed bool `json:"reversed"`
+	// Facing is the angle (in degrees) from which each row of the sheet is seen, in row order
+	Facing []float64 `json:"facing"`
+	// Clips are the named animation clips added to the clips from sprite sheet data, the first clip is played first
+	Clips []ArchetypeClip `json:"clips"`
+	// Transitions are the clips each clip can change to, with no clips for final clips (see SetAnimationTransitions)
+	Transitions map[string][]string `json:"transitions"`
+	// Velocity is the speed the sprites move at (in distance per tick) unless placed with a velocity of their own
+	Velocity float64 `json:"velocity"`
+	// Health is the health the sprites start with (0 for sprites that cannot be damaged)
+	Health float64 `json:"health"`
+}
+
+// ArchetypeClip is a named animation clip of an archetype
+type ArchetypeClip struct {
+	Name     string  `json:"name"`
+	First    int     `json:"first"`
+	Last     int     `json:"last"`
+	Rate     float64 `json:"rate"`
+	Loop     bool    `json:"loop"`
+	Reverse  bool    `json:"reverse"`
+	PingPong bool    `json:"pingPong"`
+	Next     string  `json:"next"`
+}
+
+// Clip returns the animation clip of the archetype clip
+func (c ArchetypeClip) Clip() AnimationClip {
+	return AnimationClip{
+		First: c.First, Last: c.Last, Rate: c.Rate, Loop: c.Loop,
+		Reverse: c.Reverse, PingPong: c.PingPong, Next: c.Next,
+	}
+}
+
+// SheetSize returns the columns and rows of frames in the texture, 1x1 if it has no grid
+func (a Archetype) SheetSize() (int, int) {
+	columns, rows := a.Columns, a.Rows
+	if columns < 1 {
+		columns = 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	return columns, rows
+}
+
+// LoadArchetypes reads and validates the archetype manifest at the given path of the file system
+func LoadArchetypes(fsys fs.FS, filePath string) (*ArchetypeManifest, error) {
+	data, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read archetype manifest: %w", err)
+	}
+
+	am, err := ParseArchetypes(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archetype manifest %s: %w", filePath, err)
+	}
+	return am, nil
+}
+
+// ParseArchetypes creates an archetype manifest from the contents of a JSON archetype manifest file.
+// Clip frames are checked against the texture once the archetypes are given their textures (see NewSpriteFactory).
+func ParseArchetypes(data []byte) (*ArchetypeManifest, error) {
+	var am ArchetypeManifest
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&am); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]struct{}, len(am.Archetypes))
+	for _, a := range am.Archetypes {
+		if a.Name == "" {
+			return nil, fmt.Errorf("archetype with texture %q has no name", a.Texture)
+		}
+		if _, ok := names[a.Name]; ok {
+			return nil, fmt.Errorf("archetype %q declared more than once", a.Name)
+		}
+		names[a.Name] = struct{}{}
+
+		if err := a.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &am, nil
+}
+
+// Names returns the set of archetype names, the sprite types that can be placed on maps
+func (am *ArchetypeManifest) Names() map[string]struct{} {
+	names := make(map[string]struct{}, len(am.Archetypes))
+	for _, a := range am.Archetypes {
+		names[a.Name] = struct{}{}
+	}
+	return names
+}
+
+// validate makes sure the values of the archetype are usable, apart from the frames of its clips
+func (a Archetype) validate() error {
+	if a.Texture == "" {
+		return fmt.Errorf("archetype %q has no texture", a.Name)
+	}
+	if a.Columns < 0 || a.Rows < 0 {
+		return fmt.Errorf("archetype %q has negative columns or rows", a.Name)
+	}
+	if a.Scale < 0 || a.CollisionRadius < 0 || a.CollisionHeight < 0 || a.Rate < 0 || a.Health < 0 {
+		return fmt.Errorf("archetype %q has negative scale, collision size, rate or health", a.Name)
+	}
+	if _, err := a.anchor(); err != nil {
+		return err
+	}
+
+	_, rows := a.SheetSize()
+	if len(a.Facing) > 0 && len(a.Facing) != rows {
+		return fmt.Errorf("archetype %q has %d facing angles for %d rows", a.Name, len(a.Facing), rows)
+	}
+
+	clips := make(map[string]struct{}, len(a.Clips))
+	for _, clip := range a.Clips {
+		if clip.Name == "" {
+			return fmt.Errorf("archetype %q has a clip without a name", a.Name)
+		}
+		if _, ok := clips[clip.Name]; ok {
+			return fmt.Errorf("archetype %q has clip %q more than once", a.Name, clip.Name)
+		}
+		clips[clip.Name] = struct{}{}
+		if clip.First < 0 || clip.Last < clip.First || clip.Rate < 0 {
+			return fmt.Errorf("archetype %q clip %q has invalid frames %d to %d or rate", a.Name, clip.Name, clip.First, clip.Last)
+		}
+	}
+	return nil
+}
+
+// anchor returns the raycaster anchor of the archetype anchor
+func (a Archetype) anchor() (raycaster.SpriteAnchor, error) {
+	switch a.Anchor {
+	case "", "bottom":
+		return raycaster.AnchorBottom, nil
+	case "center":
+		return raycaster.AnchorCenter, nil
+	case "top":
+		return raycaster.AnchorTop, nil
+	}
+	return raycaster.AnchorBottom, fmt.Errorf("archetype %q has unknown anchor %q", a.Name, a.Anchor)
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/harbdog/raycaster-go"
+)
+
+const testArchetypes = `{
+	"archetypes": [
+		{
+			"name": "bat", "texture": "bat_sheet", "columns": 3, "rows": 4, "scale": 0.25, "anchor": "top",
+			"collisionRadius": 14, "collisionHeight": 25, "mapColor": [255, 200, 0, 196], "rate": 5.5,
+			"facing": [0, 270, 180, 90], "velocity": 0.03, "health": 10
+		},
+		{"name": "rock", "texture": "large_rock"}
+	]
+}`
+
+func TestLoadArchetypes(t *testing.T) {
+	fsys := fstest.MapFS{"archetypes.json": {Data: []byte(testArchetypes)}}
+
+	am, err := LoadArchetypes(fsys, "archetypes.json")
+	if err != nil {
+		t.Fatalf("LoadArchetypes() error = %v", err)
+	}
+	if len(am.Archetypes) != 2 {
+		t.Fatalf("len(Archetypes) = %d, want 2", len(am.Archetypes))
+	}
+
+	bat := am.Archetypes[0]
+	if bat.Name != "bat" || bat.Texture != "bat_sheet" || bat.Scale != 0.25 || bat.Health != 10 || len(bat.Facing) != 4 {
+		t.Errorf("Archetypes[0] = %+v, want the bat archetype", bat)
+	}
+	if anchor, err := bat.anchor(); err != nil || anchor != raycaster.AnchorTop {
+		t.Errorf("bat anchor() = %v, %v, want top anchor", anchor, err)
+	}
+	if columns, rows := am.Archetypes[1].SheetSize(); columns != 1 || rows != 1 {
+		t.Errorf("rock SheetSize() = %d, %d, want 1, 1", columns, rows)
+	}
+
+	names := am.Names()
+	if _, ok := names["bat"]; !ok || len(names) != 2 {
+		t.Errorf("Names() = %v, want bat and rock", names)
+	}
+
+	if _, err := LoadArchetypes(fsys, "missing.json"); err == nil {
+		t.Error("LoadArchetypes() of a missing file succeeded, want error")
+	}
+}
+
+func TestParseArchetypesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"unknown field", `{"archetypes": [{"name": "rock", "texture": "large_rock", "size": 2}]}`, `unknown field "size"`},
+		{"no name", `{"archetypes": [{"texture": "large_rock"}]}`, `archetype with texture "large_rock" has no name`},
+		{"declared twice", `{"archetypes": [{"name": "rock", "texture": "large_rock"}, {"name": "rock", "texture": "large_rock"}]}`, `archetype "rock" declared more than once`},
+		{"no texture", `{"archetypes": [{"name": "rock"}]}`, `archetype "rock" has no texture`},
+		{"negative rows", `{"archetypes": [{"name": "rock", "texture": "large_rock", "rows": -1}]}`, `archetype "rock" has negative columns or rows`},
+		{"negative scale", `{"archetypes": [{"name": "rock", "texture": "large_rock", "scale": -1}]}`, `archetype "rock" has negative scale, collision size, rate or health`},
+		{"negative health", `{"archetypes": [{"name": "rock", "texture": "large_rock", "health": -1}]}`, `archetype "rock" has negative scale, collision size, rate or health`},
+		{"unknown anchor", `{"archetypes": [{"name": "rock", "texture": "large_rock", "anchor": "middle"}]}`, `archetype "rock" has unknown anchor "middle"`},
+		{
+			"facing rows",
+			`{"archetypes": [{"name": "bat", "texture": "bat_sheet", "columns": 3, "rows": 4, "facing": [0, 180]}]}`,
+			`archetype "bat" has 2 facing angles for 4 rows`,
+		},
+		{
+			"facing single image",
+			`{"archetypes": [{"name": "rock", "texture": "large_rock", "facing": [0, 180]}]}`,
+			`archetype "rock" has 2 facing angles for 1 rows`,
+		},
+		{"clip name", `{"archetypes": [{"name": "rock", "texture": "large_rock", "clips": [{"last": 1}]}]}`, `archetype "rock" has a clip without a name`},
+		{
+			"clip twice",
+			`{"archetypes": [{"name": "rock", "texture": "large_rock", "clips": [{"name": "idle"}, {"name": "idle"}]}]}`,
+			`archetype "rock" has clip "idle" more than once`,
+		},
+		{
+			"clip frames",
+			`{"archetypes": [{"name": "rock", "texture": "large_rock", "clips": [{"name": "idle", "first": 2, "last": 1}]}]}`,
+			`archetype "rock" clip "idle" has invalid frames 2 to 1 or rate`,
+		},
+		{
+			"clip rate",
+			`{"archetypes": [{"name": "rock", "texture": "large_rock", "clips": [{"name": "idle", "rate": -1}]}]}`,
+			`archetype "rock" clip "idle" has invalid frames 0 to 0 or rate`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseArchetypes([]byte(tt.json))
+			if err == nil {
+				t.Fatalf("ParseArchetypes() succeeded, want error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseArchetypes() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
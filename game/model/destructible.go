@@ -0,0 +1,99 @@
+package model
+
+import (
+	"fmt"
+)
+
+// DestructibleWall is a wall cell worn down by projectiles hitting it, showing the texture of its damage stage
+// and removed from the map when it has no health left
+type DestructibleWall struct {
+	MapCell
+	Health    float64
+	MaxHealth float64
+	// TexNum is the wall texture number of the undamaged wall
+	TexNum int
+	// Stages are the wall texture numbers shown as the wall gets more damaged, splitting its health evenly
+	Stages []int
+}
+
+// NewDestructibleWall creates a wall at the cell with the health and damage stage textures,
+// the undamaged wall texture is set from the map
+func NewDestructibleWall(cell MapCell, health float64, stages []int) *DestructibleWall {
+	w := &DestructibleWall{
+		MapCell:   cell,
+		Health:    health,
+		MaxHealth: health,
+		Stages:    stages,
+	}
+	return w
+}
+
+// Damage lowers the health of the wall, returning true if it changed the wall texture
+func (w *DestructibleWall) Damage(amount float64) bool {
+	texNum := w.StageTexNum()
+	w.Health -= amount
+	if w.Health < 0 {
+		w.Health = 0
+	}
+	return w.StageTexNum() != texNum
+}
+
+// IsDestroyed returns true if the wall has no health left
+func (w *DestructibleWall) IsDestroyed() bool {
+	return w.Health <= 0
+}
+
+// StageTexNum returns the wall texture number of the current damage stage (0 once destroyed)
+func (w *DestructibleWall) StageTexNum() int {
+	if w.IsDestroyed() {
+		return 0
+	}
+	stage := int((1-w.Health/w.MaxHealth)*float64(len(w.Stages)+1)) - 1
+	if stage < 0 {
+		return w.TexNum
+	}
+	if stage >= len(w.Stages) {
+		stage = len(w.Stages) - 1
+	}
+	return w.Stages[stage]
+}
+
+// initDestructibleWalls makes sure each destructible wall is on a wall cell of its own that is not a door,
+// and uses the wall at the cell as its undamaged texture
+func (m *Map) initDestructibleWalls() error {
+	cells := make(map[MapCell]struct{}, len(m.destructibles))
+	for _, w := range m.destructibles {
+		level := m.Level(w.Level)
+		if level == nil {
+			return fmt.Errorf("destructible wall at (%d, %d) is on level %d that does not exist", w.X, w.Y, w.Level)
+		}
+		if w.X < 0 || w.X >= len(level) || w.Y < 0 || w.Y >= len(level[0]) {
+			return fmt.Errorf("destructible wall at (%d, %d) is outside the map", w.X, w.Y)
+		}
+		if level[w.X][w.Y] <= 0 {
+			return fmt.Errorf("destructible wall at (%d, %d) of level %d is not on a wall", w.X, w.Y, w.Level)
+		}
+		if w.Level == 0 && m.DoorAt(w.X, w.Y) != nil {
+			return fmt.Errorf("destructible wall at (%d, %d) is on a door", w.X, w.Y)
+		}
+		if _, ok := m.faces[w.MapCell]; ok {
+			return fmt.Errorf("destructible wall at (%d, %d) of level %d cannot have faces", w.X, w.Y, w.Level)
+		}
+		if w.MaxHealth <= 0 {
+			return fmt.Errorf("destructible wall at (%d, %d) of level %d needs health greater than 0", w.X, w.Y, w.Level)
+		}
+		for _, stage := range w.Stages {
+			if stage <= 0 {
+				return fmt.Errorf("destructible wall at (%d, %d) of level %d has invalid stage texture %d", w.X, w.Y, w.Level, stage)
+			}
+		}
+
+		if _, ok := cells[w.MapCell]; ok {
+			return fmt.Errorf("more than one destructible wall at (%d, %d) of level %d", w.X, w.Y, w.Level)
+		}
+		cells[w.MapCell] = struct{}{}
+
+		w.TexNum = level[w.X][w.Y]
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package model
+
+// DoorState is the open/close state of a door
+type DoorState int
+
+const (
+	DoorClosed DoorState = iota
+	DoorOpening
+	DoorOpen
+	DoorClosing
+)
+
+const (
+	// defaultDoorCloseDelay is the number of seconds a door stays open unless the map declares otherwise
+	defaultDoorCloseDelay = 5.0
+
+	// defaultDoorSpeed is the amount of the door that slides open or closed per second
+	defaultDoorSpeed = 2.4
+)
+
+// Door is a ground level wall cell that slides open to let entities through
+type Door struct {
+	X, Y int
+	// TexNum is the wall texture number of the door cell when it is not open
+	TexNum int
+	Locked bool
+	// Auto doors open by themselves when the player walks up to them
+	Auto bool
+	// CloseDelay is the number of seconds the door stays open before closing (0 to stay open)
+	CloseDelay float64
+	// Speed is the amount of the door that slides open or closed per second
+	Speed float64
+
+	state      DoorState
+	openAmount float64
+	openTime   float64
+}
+
+func NewDoor(x, y, texNum int) *Door {
+	d := &Door{
+		X:          x,
+		Y:          y,
+		TexNum:     texNum,
+		CloseDelay: defaultDoorCloseDelay,
+		Speed:      defaultDoorSpeed,
+		state:      DoorClosed,
+	}
+	return d
+}
+
+func (d *Door) State() DoorState {
+	return d.state
+}
+
+// OpenAmount returns how far the door is open, from 0 (closed) to 1 (open)
+func (d *Door) OpenAmount() float64 {
+	return d.openAmount
+}
+
+// IsPassable returns true if the door is fully open so entities can pass through its cell
+func (d *Door) IsPassable() bool {
+	return d.state == DoorOpen
+}
+
+// Open starts opening the door, returns false if the door is locked
+func (d *Door) Open() bool {
+	if d.Locked {
+		return false
+	}
+
+	switch d.state {
+	case DoorClosed, DoorClosing:
+		d.state = DoorOpening
+	case DoorOpen:
+		// keep it open longer
+		d.openTime = 0
+	}
+	return true
+}
+
+// Close starts closing the door if it is open or opening
+func (d *Door) Close() {
+	switch d.state {
+	case DoorOpen, DoorOpening:
+		d.state = DoorClosing
+	}
+}
+
+// Update moves the door along by the number of seconds elapsed while opening or closing,
+// canClose is whether the door cell is clear of entities for the open door to start closing
+// after its close delay
+func (d *Door) Update(canClose bool, dt float64) {
+	switch d.state {
+	case DoorOpening:
+		d.openAmount += d.Speed * dt
+		if d.openAmount >= 1 {
+			d.openAmount = 1
+			d.openTime = 0
+			d.state = DoorOpen
+		}
+
+	case DoorOpen:
+		if d.CloseDelay <= 0 {
+			return
+		}
+		d.openTime += dt
+		if canClose && d.openTime >= d.CloseDelay {
+			d.state = DoorClosing
+		}
+
+	case DoorClosing:
+		d.openAmount -= d.Speed * dt
+		if d.openAmount <= 0 {
+			d.openAmount = 0
+			d.state = DoorClosed
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package model
+
+import "testing"
+
+func TestDoorUpdate(t *testing.T) {
+	// open moves a closed door all the way open at 2 per second
+	open := func(d *Door) {
+		d.Open()
+		d.Update(false, 0.5)
+	}
+
+	tests := []struct {
+		name       string
+		door       func(d *Door)
+		run        func(d *Door)
+		wantState  DoorState
+		wantAmount float64
+	}{
+		{
+			name:       "opening by elapsed time",
+			run:        func(d *Door) { d.Open(); d.Update(false, 0.25) },
+			wantState:  DoorOpening,
+			wantAmount: 0.5,
+		},
+		{
+			name:       "fully open",
+			run:        open,
+			wantState:  DoorOpen,
+			wantAmount: 1,
+		},
+		{
+			name:      "locked",
+			door:      func(d *Door) { d.Locked = true },
+			run:       open,
+			wantState: DoorClosed,
+		},
+		{
+			name:       "open until the close delay",
+			run:        func(d *Door) { open(d); d.Update(true, 0.5) },
+			wantState:  DoorOpen,
+			wantAmount: 1,
+		},
+		{
+			name:       "closing after the close delay",
+			run:        func(d *Door) { open(d); d.Update(true, 0.5); d.Update(true, 0.5) },
+			wantState:  DoorClosing,
+			wantAmount: 1,
+		},
+		{
+			name:      "closed after the close delay",
+			run:       func(d *Door) { open(d); d.Update(true, 1); d.Update(true, 0.5) },
+			wantState: DoorClosed,
+		},
+		{
+			name:       "kept open while the cell is occupied",
+			run:        func(d *Door) { open(d); d.Update(false, 2) },
+			wantState:  DoorOpen,
+			wantAmount: 1,
+		},
+		{
+			name:       "no close delay",
+			door:       func(d *Door) { d.CloseDelay = 0 },
+			run:        func(d *Door) { open(d); d.Update(true, 100) },
+			wantState:  DoorOpen,
+			wantAmount: 1,
+		},
+		{
+			name:       "opening again restarts the close delay",
+			run:        func(d *Door) { open(d); d.Update(true, 0.75); d.Open(); d.Update(true, 0.75) },
+			wantState:  DoorOpen,
+			wantAmount: 1,
+		},
+		{
+			name:       "closed by hand",
+			run:        func(d *Door) { open(d); d.Close(); d.Update(false, 0.25) },
+			wantState:  DoorClosing,
+			wantAmount: 0.5,
+		},
+		{
+			name:       "reopened while closing",
+			run:        func(d *Door) { open(d); d.Close(); d.Update(false, 0.25); d.Open(); d.Update(false, 0.125) },
+			wantState:  DoorOpening,
+			wantAmount: 0.75,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDoor(1, 1, 2)
+			d.CloseDelay = 1
+			d.Speed = 2
+			if tt.door != nil {
+				tt.door(d)
+			}
+
+			tt.run(d)
+			if d.State() != tt.wantState || d.OpenAmount() != tt.wantAmount {
+				t.Errorf("state %v open %v, want state %v open %v", d.State(), d.OpenAmount(), tt.wantState, tt.wantAmount)
+			}
+			if d.IsPassable() != (tt.wantState == DoorOpen) {
+				t.Errorf("IsPassable() = %v in state %v", d.IsPassable(), d.State())
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"image/color"
+	"math"
 
 	"github.com/harbdog/raycaster-go"
 	"github.com/harbdog/raycaster-go/geom"
@@ -19,6 +20,11 @@ type Entity struct {
 	CollisionHeight float64
 	MapColor        color.RGBA
 	Parent          *Entity
+	// Health is lost when damaged, entities without MaxHealth cannot be damaged
+	Health    float64
+	MaxHealth float64
+	// Slide is the movement per tick the entity keeps sliding along with on slippery floors
+	Slide geom.Vector2
 }
 
 func (e *Entity) Pos() *geom.Vector2 {
@@ -28,3 +34,16 @@ func (e *Entity) Pos() *geom.Vector2 {
 func (e *Entity) PosZ() float64 {
 	return e.PositionZ
 }
+
+// Damage reduces the health of the entity by the amount, if it can be damaged
+func (e *Entity) Damage(amount float64) {
+	if e.MaxHealth <= 0 {
+		return
+	}
+	e.Health = math.Max(e.Health-amount, 0)
+}
+
+// IsDead returns true if the entity can be damaged and has no health left
+func (e *Entity) IsDead() bool {
+	return e.MaxHealth > 0 && e.Health <= 0
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// Environment is the sky, floor, lighting and render distance of a map. Settings that are not set
+// (empty or nil) are left to the environment it is merged over.
+type Environment struct {
+	// Sky and Floor are the names of the sky and floor textures in the texture manifest
+	Sky   string
+	Floor string
+	// RenderDistance is how far the map is drawn, -1 for no limit
+	RenderDistance *float64
+	LightFalloff   *float64
+	Illumination   *float64
+	MinLightRGB    *color.NRGBA
+	MaxLightRGB    *color.NRGBA
+}
+
+// Merge returns the environment with the settings that are set in the other environment replaced
+func (e Environment) Merge(other Environment) Environment {
+	if other.Sky != "" {
+		e.Sky = other.Sky
+	}
+	if other.Floor != "" {
+		e.Floor = other.Floor
+	}
+	if other.RenderDistance != nil {
+		e.RenderDistance = other.RenderDistance
+	}
+	if other.LightFalloff != nil {
+		e.LightFalloff = other.LightFalloff
+	}
+	if other.Illumination != nil {
+		e.Illumination = other.Illumination
+	}
+	if other.MinLightRGB != nil {
+		e.MinLightRGB = other.MinLightRGB
+	}
+	if other.MaxLightRGB != nil {
+		e.MaxLightRGB = other.MaxLightRGB
+	}
+	return e
+}
+
+// validate makes sure the render distance and illumination of the environment are usable by the camera
+func (e Environment) validate() error {
+	if e.RenderDistance != nil && *e.RenderDistance != -1 && *e.RenderDistance <= 0 {
+		return fmt.Errorf("environment has invalid render distance %v, expected -1 or above 0", *e.RenderDistance)
+	}
+	if e.Illumination != nil && *e.Illumination < 0 {
+		return fmt.Errorf("environment has negative illumination %v", *e.Illumination)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnvironmentMerge(t *testing.T) {
+	float := func(v float64) *float64 { return &v }
+
+	base := Environment{
+		Sky: "sky", Floor: "floor", RenderDistance: float(-1), LightFalloff: float(-100), Illumination: float(5000),
+		MinLightRGB: &color.NRGBA{76, 76, 76, 255}, MaxLightRGB: &color.NRGBA{255, 255, 255, 255},
+	}
+
+	tests := []struct {
+		name  string
+		other Environment
+		want  Environment
+	}{
+		{
+			name:  "nothing set",
+			other: Environment{},
+			want:  base,
+		},
+		{
+			name:  "textures",
+			other: Environment{Sky: "night", Floor: "grass"},
+			want: Environment{
+				Sky: "night", Floor: "grass", RenderDistance: float(-1), LightFalloff: float(-100), Illumination: float(5000),
+				MinLightRGB: &color.NRGBA{76, 76, 76, 255}, MaxLightRGB: &color.NRGBA{255, 255, 255, 255},
+			},
+		},
+		{
+			name:  "lighting",
+			other: Environment{LightFalloff: float(-50), Illumination: float(0), MinLightRGB: &color.NRGBA{0, 0, 0, 255}},
+			want: Environment{
+				Sky: "sky", Floor: "floor", RenderDistance: float(-1), LightFalloff: float(-50), Illumination: float(0),
+				MinLightRGB: &color.NRGBA{0, 0, 0, 255}, MaxLightRGB: &color.NRGBA{255, 255, 255, 255},
+			},
+		},
+		{
+			name:  "render distance",
+			other: Environment{RenderDistance: float(12), MaxLightRGB: &color.NRGBA{200, 180, 160, 255}},
+			want: Environment{
+				Sky: "sky", Floor: "floor", RenderDistance: float(12), LightFalloff: float(-100), Illumination: float(5000),
+				MinLightRGB: &color.NRGBA{76, 76, 76, 255}, MaxLightRGB: &color.NRGBA{200, 180, 160, 255},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := base.Merge(tt.other); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvironmentValidate(t *testing.T) {
+	float := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name string
+		env  Environment
+		want string
+	}{
+		{"nothing set", Environment{}, ""},
+		{"no render limit", Environment{RenderDistance: float(-1)}, ""},
+		{"render distance", Environment{RenderDistance: float(8)}, ""},
+		{"zero render distance", Environment{RenderDistance: float(0)}, "environment has invalid render distance 0"},
+		{"negative render distance", Environment{RenderDistance: float(-2)}, "environment has invalid render distance -2"},
+		{"negative illumination", Environment{Illumination: float(-1)}, "environment has negative illumination -1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.env.validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("validate() error = %q, want none", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("validate() succeeded, want error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("validate() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,390 @@
+package model
+
+import (
+	"fmt"
+	"image"
+	"math/rand"
+
+	"github.com/harbdog/raycaster-go/geom"
+)
+
+const (
+	// GenerateRooms generates rectangular rooms connected by corridors
+	GenerateRooms = "rooms"
+	// GenerateCaves generates open caves using cellular automata
+	GenerateCaves = "caves"
+	// GenerateMaze generates a maze of single cell wide passages
+	GenerateMaze = "maze"
+)
+
+// GeneratorConfig configures a procedurally generated map
+type GeneratorConfig struct {
+	// Algorithm is the layout to generate: GenerateRooms, GenerateCaves or GenerateMaze
+	Algorithm string
+	// Seed makes the generated map reproducible, the same config always generates the same map
+	Seed          int64
+	Width, Height int
+	// NumLevels is the number of wall levels, walls go up through all of them
+	NumLevels int
+	// WallTextures are the wall texture numbers to pick the wall textures from
+	WallTextures []int
+	// NumSprites is the number of sprites to place, picking their types from SpriteTypes
+	NumSprites  int
+	SpriteTypes []string
+}
+
+// generator carves out the layout of the ground level, where 0 is open and any other value is the wall texture number
+type generator struct {
+	cfg   GeneratorConfig
+	rng   *rand.Rand
+	cells [][]int
+	// wallTex is the main wall texture number of the map
+	wallTex int
+}
+
+// GenerateMap generates a map with a closed border, walls on all levels, a player spawn and sprites
+func GenerateMap(cfg GeneratorConfig) (*Map, error) {
+	if cfg.Width < 8 || cfg.Height < 8 {
+		return nil, fmt.Errorf("generated map size %dx%d is smaller than 8x8", cfg.Width, cfg.Height)
+	}
+	if cfg.NumLevels < 1 {
+		return nil, fmt.Errorf("generated map needs at least 1 level")
+	}
+	if len(cfg.WallTextures) == 0 {
+		return nil, fmt.Errorf("generated map needs at least 1 wall texture")
+	}
+	if cfg.NumSprites > 0 && len(cfg.SpriteTypes) == 0 {
+		return nil, fmt.Errorf("generated map needs sprite types to place sprites")
+	}
+
+	g := &generator{
+		cfg: cfg,
+		rng: rand.New(rand.NewSource(cfg.Seed)),
+	}
+	g.wallTex = g.randomWallTexture()
+	g.fill()
+
+	var spawn image.Point
+	var err error
+	switch cfg.Algorithm {
+	case GenerateRooms:
+		spawn, err = g.rooms()
+	case GenerateCaves:
+		spawn, err = g.caves()
+	case GenerateMaze:
+		spawn, err = g.maze()
+	default:
+		err = fmt.Errorf("unknown map generator algorithm %q", cfg.Algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// walls go up through all levels
+	levels := make([][][]int, cfg.NumLevels)
+	for levelNum := range levels {
+		levels[levelNum] = make([][]int, cfg.Width)
+		for x := range levels[levelNum] {
+			levels[levelNum][x] = make([]int, cfg.Height)
+			copy(levels[levelNum][x], g.cells[x])
+		}
+	}
+
+	m := NewMap(levels)
+	m.spawn = &MapSpawn{X: float64(spawn.X) + 0.5, Y: float64(spawn.Y) + 0.5, Angle: g.rng.Float64() * geom.Pi2}
+	m.sprites = g.sprites(spawn)
+
+	if err := validateLevels(m.levels); err != nil {
+		return nil, err
+	}
+	if err := m.validatePlacements(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// fill sets all cells to walls
+func (g *generator) fill() {
+	g.cells = make([][]int, g.cfg.Width)
+	for x := range g.cells {
+		g.cells[x] = make([]int, g.cfg.Height)
+		for y := range g.cells[x] {
+			g.cells[x][y] = g.wallTex
+		}
+	}
+}
+
+func (g *generator) randomWallTexture() int {
+	return g.cfg.WallTextures[g.rng.Intn(len(g.cfg.WallTextures))]
+}
+
+// isInterior returns true if the position is inside the map border
+func (g *generator) isInterior(x, y int) bool {
+	return x > 0 && x < g.cfg.Width-1 && y > 0 && y < g.cfg.Height-1
+}
+
+// rooms places non-overlapping rooms each with their own wall texture, connecting each room
+// to the previous one with a corridor, returns the center of the first room as the spawn
+func (g *generator) rooms() (image.Point, error) {
+	w, h := g.cfg.Width, g.cfg.Height
+	maxRoomWidth, maxRoomHeight := w/4, h/4
+
+	rooms := []image.Rectangle{}
+	for attempt := 0; attempt < w*h/8; attempt++ {
+		roomW, roomH := 3+g.rng.Intn(maxRoomWidth-1), 3+g.rng.Intn(maxRoomHeight-1)
+		x, y := 1+g.rng.Intn(w-roomW-2), 1+g.rng.Intn(h-roomH-2)
+		room := image.Rect(x, y, x+roomW, y+roomH)
+
+		overlaps := false
+		for _, other := range rooms {
+			// keep at least one wall between rooms
+			if room.Inset(-1).Overlaps(other) {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			continue
+		}
+
+		// texture the walls around the room before carving it out
+		roomTex := g.randomWallTexture()
+		walls := room.Inset(-1)
+		for x := walls.Min.X; x < walls.Max.X; x++ {
+			for y := walls.Min.Y; y < walls.Max.Y; y++ {
+				if g.cells[x][y] > 0 {
+					g.cells[x][y] = roomTex
+				}
+			}
+		}
+		g.carve(room)
+
+		if len(rooms) > 0 {
+			g.corridor(center(rooms[len(rooms)-1]), center(room))
+		}
+		rooms = append(rooms, room)
+	}
+
+	if len(rooms) == 0 {
+		return image.Point{}, fmt.Errorf("no room fits in a %dx%d map", w, h)
+	}
+	return center(rooms[0]), nil
+}
+
+func center(r image.Rectangle) image.Point {
+	return image.Pt((r.Min.X+r.Max.X)/2, (r.Min.Y+r.Max.Y)/2)
+}
+
+func (g *generator) carve(r image.Rectangle) {
+	for x := r.Min.X; x < r.Max.X; x++ {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			if g.isInterior(x, y) {
+				g.cells[x][y] = 0
+			}
+		}
+	}
+}
+
+// corridor carves an L shaped corridor between two points, randomly going horizontal or vertical first
+func (g *generator) corridor(from, to image.Point) {
+	corner := image.Pt(to.X, from.Y)
+	if g.rng.Intn(2) == 0 {
+		corner = image.Pt(from.X, to.Y)
+	}
+	g.carveLine(from, corner)
+	g.carveLine(corner, to)
+}
+
+func (g *generator) carveLine(from, to image.Point) {
+	minX, maxX := from.X, to.X
+	if minX > maxX {
+		minX, maxX = maxX, minX
+	}
+	minY, maxY := from.Y, to.Y
+	if minY > maxY {
+		minY, maxY = maxY, minY
+	}
+	g.carve(image.Rect(minX, minY, maxX+1, maxY+1))
+}
+
+// caves randomly fills the map and smooths it into caves with cellular automata,
+// keeping only the largest cave so all open positions can be reached, returns a random position in it as the spawn
+func (g *generator) caves() (image.Point, error) {
+	w, h := g.cfg.Width, g.cfg.Height
+
+	for attempt := 0; attempt < 10; attempt++ {
+		for x := 1; x < w-1; x++ {
+			for y := 1; y < h-1; y++ {
+				if g.rng.Float64() < 0.45 {
+					g.cells[x][y] = g.wallTex
+				} else {
+					g.cells[x][y] = 0
+				}
+			}
+		}
+
+		for step := 0; step < 5; step++ {
+			next := make([][]int, w)
+			for x := range next {
+				next[x] = make([]int, h)
+				copy(next[x], g.cells[x])
+			}
+			for x := 1; x < w-1; x++ {
+				for y := 1; y < h-1; y++ {
+					if g.countWallNeighbors(x, y) >= 5 {
+						next[x][y] = g.wallTex
+					} else {
+						next[x][y] = 0
+					}
+				}
+			}
+			g.cells = next
+		}
+
+		// keep the largest cave, filling in the others
+		largest := g.largestRegion()
+		if len(largest)*4 < (w-2)*(h-2) {
+			// too small to be interesting, try again
+			continue
+		}
+		inLargest := make(map[image.Point]struct{}, len(largest))
+		for _, p := range largest {
+			inLargest[p] = struct{}{}
+		}
+		for x := 1; x < w-1; x++ {
+			for y := 1; y < h-1; y++ {
+				if _, ok := inLargest[image.Pt(x, y)]; !ok {
+					g.cells[x][y] = g.wallTex
+				}
+			}
+		}
+
+		g.accentWalls()
+		return largest[g.rng.Intn(len(largest))], nil
+	}
+
+	return image.Point{}, fmt.Errorf("unable to generate large enough caves in a %dx%d map", w, h)
+}
+
+func (g *generator) countWallNeighbors(x, y int) int {
+	count := 0
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			if (dx != 0 || dy != 0) && g.cells[x+dx][y+dy] > 0 {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// largestRegion returns the positions of the largest area of connected open positions
+func (g *generator) largestRegion() []image.Point {
+	w, h := g.cfg.Width, g.cfg.Height
+	visited := make([][]bool, w)
+	for x := range visited {
+		visited[x] = make([]bool, h)
+	}
+
+	var largest []image.Point
+	for x := 1; x < w-1; x++ {
+		for y := 1; y < h-1; y++ {
+			if visited[x][y] || g.cells[x][y] > 0 {
+				continue
+			}
+
+			region := []image.Point{}
+			queue := []image.Point{image.Pt(x, y)}
+			visited[x][y] = true
+			for len(queue) > 0 {
+				p := queue[0]
+				queue = queue[1:]
+				region = append(region, p)
+
+				for _, n := range []image.Point{image.Pt(p.X+1, p.Y), image.Pt(p.X-1, p.Y), image.Pt(p.X, p.Y+1), image.Pt(p.X, p.Y-1)} {
+					if !g.isInterior(n.X, n.Y) || visited[n.X][n.Y] || g.cells[n.X][n.Y] > 0 {
+						continue
+					}
+					visited[n.X][n.Y] = true
+					queue = append(queue, n)
+				}
+			}
+
+			if len(region) > len(largest) {
+				largest = region
+			}
+		}
+	}
+	return largest
+}
+
+// maze carves a maze with a randomized depth-first search through the odd positions of the map,
+// returns the top left corner of the maze as the spawn
+func (g *generator) maze() (image.Point, error) {
+	start := image.Pt(1, 1)
+	g.cells[start.X][start.Y] = 0
+
+	stack := []image.Point{start}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+
+		// find the neighboring maze positions not yet carved out
+		neighbors := []image.Point{}
+		for _, d := range []image.Point{image.Pt(2, 0), image.Pt(-2, 0), image.Pt(0, 2), image.Pt(0, -2)} {
+			n := p.Add(d)
+			if g.isInterior(n.X, n.Y) && g.cells[n.X][n.Y] > 0 {
+				neighbors = append(neighbors, n)
+			}
+		}
+
+		if len(neighbors) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		// carve out the neighbor and the wall between them
+		n := neighbors[g.rng.Intn(len(neighbors))]
+		g.cells[(p.X+n.X)/2][(p.Y+n.Y)/2] = 0
+		g.cells[n.X][n.Y] = 0
+		stack = append(stack, n)
+	}
+
+	g.accentWalls()
+	return start, nil
+}
+
+// accentWalls gives some of the walls a random wall texture for variety
+func (g *generator) accentWalls() {
+	for x := range g.cells {
+		for y := range g.cells[x] {
+			if g.cells[x][y] > 0 && g.rng.Float64() < 0.1 {
+				g.cells[x][y] = g.randomWallTexture()
+			}
+		}
+	}
+}
+
+// sprites places sprites at the center of random open positions other than the spawn
+func (g *generator) sprites(spawn image.Point) []MapSprite {
+	open := []image.Point{}
+	for x := range g.cells {
+		for y := range g.cells[x] {
+			if g.cells[x][y] <= 0 && (x != spawn.X || y != spawn.Y) {
+				open = append(open, image.Pt(x, y))
+			}
+		}
+	}
+	g.rng.Shuffle(len(open), func(i, j int) {
+		open[i], open[j] = open[j], open[i]
+	})
+
+	sprites := []MapSprite{}
+	for i := 0; i < g.cfg.NumSprites && i < len(open); i++ {
+		sprites = append(sprites, MapSprite{
+			Type: g.cfg.SpriteTypes[g.rng.Intn(len(g.cfg.SpriteTypes))],
+			X:    float64(open[i].X) + 0.5,
+			Y:    float64(open[i].Y) + 0.5,
+		})
+	}
+	return sprites
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testGeneratorConfig(algorithm string, seed int64) GeneratorConfig {
+	return GeneratorConfig{
+		Algorithm: algorithm, Seed: seed,
+		Width: 40, Height: 30, NumLevels: 2,
+		WallTextures: []int{1, 2, 7},
+		NumSprites:   10, SpriteTypes: []string{"bat", "walker"},
+	}
+}
+
+func TestGenerateMap(t *testing.T) {
+	for _, algorithm := range []string{GenerateRooms, GenerateCaves, GenerateMaze} {
+		for seed := int64(1); seed <= 5; seed++ {
+			cfg := testGeneratorConfig(algorithm, seed)
+			m, err := GenerateMap(cfg)
+			if err != nil {
+				t.Fatalf("%s seed %d: %v", algorithm, seed, err)
+			}
+
+			if m.NumLevels() != cfg.NumLevels {
+				t.Errorf("%s seed %d: %d levels, want %d", algorithm, seed, m.NumLevels(), cfg.NumLevels)
+			}
+			ground := m.Level(0)
+			if len(ground) != cfg.Width || len(ground[0]) != cfg.Height {
+				t.Fatalf("%s seed %d: size %dx%d, want %dx%d", algorithm, seed, len(ground), len(ground[0]), cfg.Width, cfg.Height)
+			}
+			if !reflect.DeepEqual(m.Level(1), ground) {
+				t.Errorf("%s seed %d: upper level walls differ from the ground level", algorithm, seed)
+			}
+
+			for x := range ground {
+				for y, value := range ground[x] {
+					border := x == 0 || y == 0 || x == cfg.Width-1 || y == cfg.Height-1
+					if border && value <= 0 {
+						t.Errorf("%s seed %d: border at (%d, %d) is open", algorithm, seed, x, y)
+					}
+					if value > 0 && value != 1 && value != 2 && value != 7 {
+						t.Errorf("%s seed %d: wall texture %d at (%d, %d) is not one of the configured textures", algorithm, seed, value, x, y)
+					}
+				}
+			}
+
+			spawn := m.Spawn()
+			reachable := reachableFrom(ground, image.Pt(int(spawn.X), int(spawn.Y)))
+			if len(m.Sprites()) != cfg.NumSprites {
+				t.Errorf("%s seed %d: %d sprites, want %d", algorithm, seed, len(m.Sprites()), cfg.NumSprites)
+			}
+			for _, sprite := range m.Sprites() {
+				if _, ok := reachable[image.Pt(int(sprite.X), int(sprite.Y))]; !ok {
+					t.Errorf("%s seed %d: %s sprite at (%v, %v) cannot be reached from the spawn", algorithm, seed, sprite.Type, sprite.X, sprite.Y)
+				}
+			}
+			for x := range ground {
+				for y, value := range ground[x] {
+					if _, ok := reachable[image.Pt(x, y)]; value <= 0 && !ok {
+						t.Errorf("%s seed %d: open position (%d, %d) cannot be reached from the spawn", algorithm, seed, x, y)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateMapSeed(t *testing.T) {
+	for _, algorithm := range []string{GenerateRooms, GenerateCaves, GenerateMaze} {
+		a, err := GenerateMap(testGeneratorConfig(algorithm, 42))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := GenerateMap(testGeneratorConfig(algorithm, 42))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(a.levels, b.levels) || !reflect.DeepEqual(a.Spawn(), b.Spawn()) || !reflect.DeepEqual(a.Sprites(), b.Sprites()) {
+			t.Errorf("%s: the same seed generated different maps", algorithm)
+		}
+
+		c, err := GenerateMap(testGeneratorConfig(algorithm, 43))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reflect.DeepEqual(a.levels, c.levels) {
+			t.Errorf("%s: different seeds generated the same walls", algorithm)
+		}
+	}
+}
+
+func TestGenerateMapErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *GeneratorConfig)
+		want   string
+	}{
+		{"size", func(cfg *GeneratorConfig) { cfg.Width = 7 }, "generated map size 7x30 is smaller than 8x8"},
+		{"levels", func(cfg *GeneratorConfig) { cfg.NumLevels = 0 }, "at least 1 level"},
+		{"wall textures", func(cfg *GeneratorConfig) { cfg.WallTextures = nil }, "at least 1 wall texture"},
+		{"sprite types", func(cfg *GeneratorConfig) { cfg.SpriteTypes = nil }, "sprite types to place sprites"},
+		{"algorithm", func(cfg *GeneratorConfig) { cfg.Algorithm = "islands" }, `unknown map generator algorithm "islands"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testGeneratorConfig(GenerateRooms, 1)
+			tt.modify(&cfg)
+			_, err := GenerateMap(cfg)
+			if err == nil {
+				t.Fatalf("GenerateMap() succeeded, want error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("GenerateMap() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// reachableFrom returns the open positions connected to the start position, moving horizontally and vertically
+func reachableFrom(level [][]int, start image.Point) map[image.Point]struct{} {
+	reachable := map[image.Point]struct{}{start: {}}
+	queue := []image.Point{start}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, d := range []image.Point{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			n := p.Add(d)
+			if n.X < 0 || n.Y < 0 || n.X >= len(level) || n.Y >= len(level[0]) || level[n.X][n.Y] > 0 {
+				continue
+			}
+			if _, ok := reachable[n]; !ok {
+				reachable[n] = struct{}{}
+				queue = append(queue, n)
+			}
+		}
+	}
+	return reachable
+}
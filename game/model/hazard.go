@@ -0,0 +1,42 @@
+package model
+
+// Hazard is a property of the floor of a map position that affects entities standing on it
+type Hazard int
+
+const (
+	HazardNone Hazard = iota
+	HazardLava
+	HazardWater
+	HazardMud
+	HazardIce
+
+	// numHazards is the number of hazard values, used to validate hazard layers
+	numHazards
+)
+
+// HazardEffect is how a hazard affects the entities standing on it
+type HazardEffect struct {
+	// Damage is the health lost per second
+	Damage float64
+	// Speed multiplies the movement speed
+	Speed float64
+	// Friction is how much of the movement is taken over each tick (1 for full control),
+	// with lower values taking longer to get moving and to stop sliding
+	Friction float64
+}
+
+var hazardEffects = [numHazards]HazardEffect{
+	HazardNone:  {Speed: 1, Friction: 1},
+	HazardLava:  {Damage: 25, Speed: 0.6, Friction: 1},
+	HazardWater: {Speed: 0.5, Friction: 1},
+	HazardMud:   {Speed: 0.35, Friction: 1},
+	HazardIce:   {Speed: 1, Friction: 0.04},
+}
+
+// Effect returns how the hazard affects the entities standing on it
+func (h Hazard) Effect() HazardEffect {
+	if h < 0 || h >= numHazards {
+		return hazardEffects[HazardNone]
+	}
+	return hazardEffects[h]
+}
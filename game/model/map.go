@@ -1,125 +1,230 @@
 package model
 
-import "github.com/harbdog/raycaster-go/geom"
+import (
+	"image"
+
+	"github.com/harbdog/raycaster-go/geom"
+)
 
 type Map struct {
-	worldMap [][]int
-	midMap   [][]int
-	upMap    [][]int
+	levels   [][][]int
+	floor    [][]int
+	hazards  [][]int
+	spawn    *MapSpawn
+	sprites  []MapSprite
+	tiles    []MapTile
+	doors    []*Door
+	triggers []*Trigger
+	faces    map[MapCell]MapFaces
+
+	// destructibles are the wall cells worn down by projectiles
+	destructibles []*DestructibleWall
+
+	// environment is the sky, floor and lighting of the map
+	environment Environment
+
+	// defaultFloor is the floor texture number of positions without one in the floor layer
+	defaultFloor int
+}
+
+// MapSpawn is the player starting position and heading angle (in radians) declared by a map
+type MapSpawn struct {
+	X, Y  float64
+	Angle float64
+}
+
+// MapSprite is the placement of a sprite declared by a map, Type names the kind of sprite to create
+type MapSprite struct {
+	Type     string
+	X, Y, Z  float64
+	Angle    float64
+	Velocity float64
+	// Scale overrides the default scale of the sprite type (0 to use the default)
+	Scale float64
+}
+
+// Face is one of the four sides of a map cell, north being the side facing toward y=0
+// and west being the side facing toward x=0
+type Face int
+
+const (
+	FaceNorth Face = iota
+	FaceSouth
+	FaceEast
+	FaceWest
+)
+
+// MapCell is the position of a cell on one of the wall levels of a map
+type MapCell struct {
+	Level, X, Y int
+}
+
+// MapFaces are the wall texture numbers of each face of a cell indexed by Face (0 to use the cell texture)
+type MapFaces [4]int
+
+// MapTile is a texture declared by a map for one of its wall texture numbers,
+// using the area Rect of the image file at Image to texture the walls with texture number ID
+type MapTile struct {
+	ID    int
+	Image string
+	Rect  image.Rectangle
 }
 
+// NewMap creates a map from the given wall levels, each level is indexed by [x][y] with the value
+// being the wall texture number at that position (0 for no wall)
+func NewMap(levels [][][]int) *Map {
+	m := &Map{
+		levels: levels,
+	}
+	return m
+}
+
+// NumLevels returns the number of wall levels declared by the map
 func (m *Map) NumLevels() int {
-	return 4
+	return len(m.levels)
 }
 
+// Level returns the wall level grid for the level number (nil if the map does not have that level)
 func (m *Map) Level(levelNum int) [][]int {
-	if levelNum == 0 {
-		return m.worldMap
-	} else if levelNum == 1 {
-		return m.midMap
-	} else {
-		return m.upMap // if above highest level just keep extending last one up
+	if levelNum < 0 || levelNum >= len(m.levels) {
+		return nil
 	}
+	return m.levels[levelNum]
 }
 
-func NewMap() *Map {
-	m := &Map{}
-
-	m.worldMap = [][]int{
-		{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 3, 2, 3, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 3, 2, 3, 2, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 6, 1, 1, 0, 0, 2, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 1, 0, 0, 0, 0, 0, 1, 1, 0, 1, 1, 0, 0, 0, 0, 0, 1, 1, 0, 1, 1},
-		{1, 0, 1, 0, 1, 0, 0, 0, 0, 1, 1, 0, 1, 1, 0, 0, 1, 0, 0, 1, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 0, 1, 1, 0, 0, 0, 0, 0, 1, 0, 0, 0, 1},
-		{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+// WallAt returns the wall texture number of a face of the cell at the map position of a level (0 for no wall)
+func (m *Map) WallAt(levelNum, x, y int, face Face) int {
+	value := layerValue(m.Level(levelNum), x, y)
+	if value <= 0 {
+		return value
 	}
+	if faces, ok := m.faces[MapCell{Level: levelNum, X: x, Y: y}]; ok && faces[face] > 0 {
+		return faces[face]
+	}
+	return value
+}
+
+// Faces returns the wall texture numbers of the cells declared with different textures per face
+func (m *Map) Faces() map[MapCell]MapFaces {
+	return m.faces
+}
 
-	m.midMap = [][]int{
-		{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 4, 5, 4, 3, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 5, 4, 5, 2, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 4, 5, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 5, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 0, 1, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 1},
-		{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+// FloorAt returns the floor texture number at the map position (0 for the built-in floor)
+func (m *Map) FloorAt(x, y int) int {
+	if value := layerValue(m.floor, x, y); value > 0 {
+		return value
 	}
+	return m.defaultFloor
+}
+
+// HazardAt returns the hazard of the floor at the map position
+func (m *Map) HazardAt(x, y int) Hazard {
+	return Hazard(layerValue(m.hazards, x, y))
+}
 
-	m.upMap = [][]int{
-		{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
-		{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+func layerValue(layer [][]int, x, y int) int {
+	if x < 0 || x >= len(layer) || y < 0 || y >= len(layer[x]) {
+		return 0
 	}
+	return layer[x][y]
+}
 
-	return m
+// Spawn returns the player spawn declared by the map
+func (m *Map) Spawn() *MapSpawn {
+	return m.spawn
+}
+
+// Sprites returns the sprite placements declared by the map
+func (m *Map) Sprites() []MapSprite {
+	return m.sprites
+}
+
+// Tiles returns the wall textures declared by the map
+func (m *Map) Tiles() []MapTile {
+	return m.tiles
+}
+
+// Doors returns the doors of the map
+func (m *Map) Doors() []*Door {
+	return m.doors
+}
+
+// DoorAt returns the door at the map position (nil if there is no door there)
+func (m *Map) DoorAt(x, y int) *Door {
+	for _, door := range m.doors {
+		if door.X == x && door.Y == y {
+			return door
+		}
+	}
+	return nil
+}
+
+// Destructibles returns the destructible walls of the map
+func (m *Map) Destructibles() []*DestructibleWall {
+	return m.destructibles
+}
+
+// DestructibleAt returns the destructible wall at the map position of a level (nil if there is none)
+func (m *Map) DestructibleAt(levelNum, x, y int) *DestructibleWall {
+	for _, w := range m.destructibles {
+		if w.Level == levelNum && w.X == x && w.Y == y {
+			return w
+		}
+	}
+	return nil
 }
 
-func (m *Map) GetCollisionLines(clipDistance float64) []geom.Line {
-	if len(m.worldMap) == 0 || len(m.worldMap[0]) == 0 {
+// Triggers returns the trigger areas of the map
+func (m *Map) Triggers() []*Trigger {
+	return m.triggers
+}
+
+// Environment returns the sky, floor and lighting settings of the map, unset settings are left to the game
+func (m *Map) Environment() Environment {
+	return m.environment
+}
+
+// SetWall changes the wall texture number at the map position of a level (0 for no wall)
+func (m *Map) SetWall(levelNum, x, y, value int) {
+	level := m.Level(levelNum)
+	if x < 0 || x >= len(level) || y < 0 || y >= len(level[x]) {
+		return
+	}
+	level[x][y] = value
+}
+
+// GetBoundaryLines returns the collision lines around the edge of the map
+func (m *Map) GetBoundaryLines(clipDistance float64) []geom.Line {
+	worldMap := m.Level(0)
+	if len(worldMap) == 0 || len(worldMap[0]) == 0 {
 		return []geom.Line{}
 	}
 
-	lines := geom.Rect(clipDistance, clipDistance,
-		float64(len(m.worldMap))-2*clipDistance, float64(len(m.worldMap[0]))-2*clipDistance)
+	return geom.Rect(clipDistance, clipDistance,
+		float64(len(worldMap))-2*clipDistance, float64(len(worldMap[0]))-2*clipDistance)
+}
+
+// GetCollisionLines returns the collision lines around the walls of a level
+func (m *Map) GetCollisionLines(levelNum int, clipDistance float64) []geom.Line {
+	level := m.Level(levelNum)
+	if len(level) == 0 {
+		return []geom.Line{}
+	}
+	return m.GetAreaCollisionLines(levelNum, image.Rect(0, 0, len(level), len(level[0])), clipDistance)
+}
+
+// GetAreaCollisionLines returns the collision lines around the walls of a level within the area of map positions
+func (m *Map) GetAreaCollisionLines(levelNum int, area image.Rectangle, clipDistance float64) []geom.Line {
+	lines := []geom.Line{}
+	level := m.Level(levelNum)
+	if len(level) == 0 {
+		return lines
+	}
 
-	for x, row := range m.worldMap {
-		for y, value := range row {
-			if value > 0 {
+	area = area.Intersect(image.Rect(0, 0, len(level), len(level[0])))
+	for x := area.Min.X; x < area.Max.X; x++ {
+		for y := area.Min.Y; y < area.Max.Y; y++ {
+			if level[x][y] > 0 {
 				lines = append(lines, geom.Rect(float64(x)-clipDistance, float64(y)-clipDistance,
 					1.0+(2*clipDistance), 1.0+(2*clipDistance))...)
 			}
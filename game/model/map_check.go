@@ -0,0 +1,200 @@
+package model
+
+import (
+	"fmt"
+	"image"
+	"sort"
+)
+
+// CheckMap looks for problems in a map that the level file format itself does not prevent,
+// given the wall texture numbers that have a texture and the sprite types that have an archetype.
+// Returns a description of each problem found.
+func CheckMap(m *Map, textureIDs map[int]struct{}, spriteTypes map[string]struct{}) []string {
+	problems := []string{}
+	problems = append(problems, checkBorder(m)...)
+	problems = append(problems, checkTextures(m, textureIDs)...)
+	problems = append(problems, checkSprites(m)...)
+	problems = append(problems, checkSpriteTypes(m, spriteTypes)...)
+	problems = append(problems, checkReachable(m)...)
+	return problems
+}
+
+// checkBorder makes sure the ground level is closed off by walls all around its edge,
+// so entities cannot move off the map
+func checkBorder(m *Map) []string {
+	problems := []string{}
+	worldMap := m.Level(0)
+	width, height := len(worldMap), len(worldMap[0])
+
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			if x != 0 && y != 0 && x != width-1 && y != height-1 {
+				continue
+			}
+			if worldMap[x][y] <= 0 {
+				problems = append(problems, fmt.Sprintf("border is open at (%d, %d)", x, y))
+			} else if m.DoorAt(x, y) != nil {
+				problems = append(problems, fmt.Sprintf("border has a door at (%d, %d)", x, y))
+			}
+		}
+	}
+	return problems
+}
+
+// checkTextures makes sure every texture number used by the map has a texture
+func checkTextures(m *Map, textureIDs map[int]struct{}) []string {
+	// texture numbers used by the map, with where each was first used
+	used := make(map[int]string)
+	use := func(value int, where string, args ...interface{}) {
+		if _, ok := used[value]; value > 0 && !ok {
+			used[value] = fmt.Sprintf(where, args...)
+		}
+	}
+
+	for levelNum := 0; levelNum < m.NumLevels(); levelNum++ {
+		for x, column := range m.Level(levelNum) {
+			for y, value := range column {
+				use(value, "level %d at (%d, %d)", levelNum, x, y)
+			}
+		}
+	}
+	for x, column := range m.floor {
+		for y, value := range column {
+			use(value, "floor at (%d, %d)", x, y)
+		}
+	}
+	use(m.defaultFloor, "default floor")
+	for cell, faces := range m.faces {
+		for _, value := range faces {
+			use(value, "faces of level %d at (%d, %d)", cell.Level, cell.X, cell.Y)
+		}
+	}
+	for _, w := range m.destructibles {
+		for _, value := range w.Stages {
+			use(value, "destructible wall stages of level %d at (%d, %d)", w.Level, w.X, w.Y)
+		}
+	}
+
+	// textures declared by the map are loaded over the other textures
+	available := make(map[int]struct{}, len(textureIDs)+len(m.tiles))
+	for id := range textureIDs {
+		available[id] = struct{}{}
+	}
+	for _, tile := range m.tiles {
+		available[tile.ID] = struct{}{}
+	}
+
+	values := make([]int, 0, len(used))
+	for value := range used {
+		values = append(values, value)
+	}
+	sort.Ints(values)
+
+	problems := []string{}
+	for _, value := range values {
+		if _, ok := available[value]; !ok {
+			problems = append(problems, fmt.Sprintf("wall texture number %d has no texture, used by %s", value, used[value]))
+		}
+	}
+	return problems
+}
+
+// checkSprites makes sure sprites are placed within the map and not inside walls
+func checkSprites(m *Map) []string {
+	problems := []string{}
+	worldMap := m.Level(0)
+	width, height := float64(len(worldMap)), float64(len(worldMap[0]))
+
+	for _, sprite := range m.sprites {
+		if sprite.X < 0 || sprite.X >= width || sprite.Y < 0 || sprite.Y >= height {
+			problems = append(problems, fmt.Sprintf("%s sprite at (%v, %v) is outside the map", sprite.Type, sprite.X, sprite.Y))
+			continue
+		}
+
+		// check the level at the height of the sprite
+		levelNum := int(sprite.Z)
+		if levelNum < 0 || levelNum >= m.NumLevels() {
+			continue
+		}
+		if m.Level(levelNum)[int(sprite.X)][int(sprite.Y)] > 0 {
+			problems = append(problems, fmt.Sprintf("%s sprite at (%v, %v) is inside a wall on level %d",
+				sprite.Type, sprite.X, sprite.Y, levelNum))
+		}
+	}
+	return problems
+}
+
+// checkSpriteTypes makes sure every sprite placed or spawned by the map has an archetype
+func checkSpriteTypes(m *Map, spriteTypes map[string]struct{}) []string {
+	problems := []string{}
+	for _, sprite := range m.sprites {
+		if _, ok := spriteTypes[sprite.Type]; !ok {
+			problems = append(problems, fmt.Sprintf("%s sprite at (%v, %v) has no archetype", sprite.Type, sprite.X, sprite.Y))
+		}
+	}
+	for _, trigger := range m.triggers {
+		for _, action := range trigger.Actions {
+			if _, ok := spriteTypes[action.Sprite]; action.Type == ActionSpawn && !ok {
+				problems = append(problems, fmt.Sprintf("trigger at (%v, %v) spawns %s sprite that has no archetype",
+					trigger.X, trigger.Y, action.Sprite))
+			}
+		}
+	}
+	return problems
+}
+
+// checkReachable makes sure all open ground level positions can be reached from the player spawn,
+// counting doors as open
+func checkReachable(m *Map) []string {
+	problems := []string{}
+	if m.spawn == nil {
+		return append(problems, "no player spawn declared")
+	}
+
+	worldMap := m.Level(0)
+	width, height := len(worldMap), len(worldMap[0])
+	isOpen := func(p image.Point) bool {
+		return p.X >= 0 && p.X < width && p.Y >= 0 && p.Y < height && (worldMap[p.X][p.Y] <= 0 || m.DoorAt(p.X, p.Y) != nil)
+	}
+
+	start := image.Pt(int(m.spawn.X), int(m.spawn.Y))
+	if !isOpen(start) {
+		return append(problems, fmt.Sprintf("player spawn at (%v, %v) is not on an open position", m.spawn.X, m.spawn.Y))
+	}
+
+	// flood fill the open positions from the spawn, then from each open position not reached yet
+	// to report each unreachable area once
+	reached := make(map[image.Point]struct{})
+	fill := func(from image.Point) int {
+		count := 0
+		queue := []image.Point{from}
+		reached[from] = struct{}{}
+		for len(queue) > 0 {
+			p := queue[0]
+			queue = queue[1:]
+			count++
+
+			for _, n := range []image.Point{image.Pt(p.X+1, p.Y), image.Pt(p.X-1, p.Y), image.Pt(p.X, p.Y+1), image.Pt(p.X, p.Y-1)} {
+				if _, ok := reached[n]; ok || !isOpen(n) {
+					continue
+				}
+				reached[n] = struct{}{}
+				queue = append(queue, n)
+			}
+		}
+		return count
+	}
+
+	fill(start)
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			p := image.Pt(x, y)
+			if _, ok := reached[p]; ok || !isOpen(p) {
+				continue
+			}
+			count := fill(p)
+			problems = append(problems, fmt.Sprintf("%d open positions from (%d, %d) cannot be reached from the player spawn", count, x, y))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckMap(t *testing.T) {
+	const spawn = `"spawn": {"x": 1.5, "y": 1.5}`
+	// walledRooms is a 5x4 level with two rooms of open positions separated by a wall
+	const walledRooms = `[[1,1,1,1],[1,0,1,1],[1,1,1,1],[1,0,0,1],[1,1,1,1]]`
+
+	textureIDs := map[int]struct{}{1: {}, 2: {}, 3: {}}
+	spriteTypes := map[string]struct{}{"bat": {}}
+
+	tests := []struct {
+		name string
+		json string
+		want []string
+	}{
+		{
+			"no problems",
+			`{"levels": [` + testRoom + `], "floor": [[0,0,0,0],[0,3,3,0],[0,3,3,0],[0,0,0,0]], ` + spawn + `, "sprites": [{"type": "bat", "x": 2.5, "y": 2.5}]}`,
+			[]string{},
+		},
+		{
+			"open border",
+			`{"levels": [[[1,1,1,1],[1,0,0,1],[1,0,0,0],[1,1,1,1]]], ` + spawn + `}`,
+			[]string{"border is open at (2, 3)"},
+		},
+		{
+			"door on border",
+			`{"levels": [` + testRoom + `], ` + spawn + `, "doors": [{"x": 3, "y": 1}]}`,
+			[]string{"border has a door at (3, 1)"},
+		},
+		{
+			"missing wall texture",
+			`{"levels": [[[1,1,1,1],[1,0,0,1],[1,0,0,1],[1,1,7,7]]], ` + spawn + `}`,
+			[]string{"wall texture number 7 has no texture, used by level 0 at (3, 2)"},
+		},
+		{
+			"missing floor textures",
+			`{"levels": [` + testRoom + `], "floor": [[0,0,0,0],[0,9,0,0],[0,0,0,0],[0,0,0,0]], "defaultFloor": 8, ` + spawn + `}`,
+			[]string{
+				"wall texture number 8 has no texture, used by default floor",
+				"wall texture number 9 has no texture, used by floor at (1, 1)",
+			},
+		},
+		{
+			"missing face texture",
+			`{"levels": [` + testRoom + `], ` + spawn + `, "faces": [{"x": 0, "y": 1, "east": 5}]}`,
+			[]string{"wall texture number 5 has no texture, used by faces of level 0 at (0, 1)"},
+		},
+		{
+			"sprite inside wall",
+			`{"levels": [` + testRoom + `], ` + spawn + `, "sprites": [{"type": "bat", "x": 0.5, "y": 0.5}]}`,
+			[]string{"bat sprite at (0.5, 0.5) is inside a wall on level 0"},
+		},
+		{
+			"sprite inside upper wall",
+			`{"levels": [` + testRoom + `, [[0,0,0,0],[0,0,0,0],[0,0,2,0],[0,0,0,0]]], ` + spawn + `, "sprites": [{"type": "bat", "x": 2.5, "y": 2.5, "z": 1.5}]}`,
+			[]string{"bat sprite at (2.5, 2.5) is inside a wall on level 1"},
+		},
+		{
+			"sprite below upper wall",
+			`{"levels": [` + testRoom + `, [[0,0,0,0],[0,0,0,0],[0,0,2,0],[0,0,0,0]]], ` + spawn + `, "sprites": [{"type": "bat", "x": 2.5, "y": 2.5, "z": 0.5}]}`,
+			[]string{},
+		},
+		{
+			"sprite without archetype",
+			`{"levels": [` + testRoom + `], ` + spawn + `, "sprites": [{"type": "walker", "x": 2.5, "y": 2.5}]}`,
+			[]string{"walker sprite at (2.5, 2.5) has no archetype"},
+		},
+		{
+			"spawned sprite without archetype",
+			`{"levels": [` + testRoom + `], ` + spawn + `, "triggers": [{"x": 1, "y": 1, "once": true, "actions": [{"type": "spawn", "sprite": "walker", "x": 2.5, "y": 2.5}]}]}`,
+			[]string{"trigger at (1, 1) spawns walker sprite that has no archetype"},
+		},
+		{
+			"unreachable room",
+			`{"levels": [` + walledRooms + `], ` + spawn + `}`,
+			[]string{"2 open positions from (3, 1) cannot be reached from the player spawn"},
+		},
+		{
+			"room behind door",
+			`{"levels": [` + walledRooms + `], ` + spawn + `, "doors": [{"x": 2, "y": 1}]}`,
+			[]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseMap([]byte(tt.json))
+			if err != nil {
+				t.Fatalf("ParseMap() error = %v", err)
+			}
+			if got := CheckMap(m, textureIDs, spriteTypes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckMap() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
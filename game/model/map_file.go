@@ -0,0 +1,457 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/harbdog/raycaster-go/geom"
+)
+
+// mapFile is the JSON level file format (see "Level files" in README.md)
+type mapFile struct {
+	// Levels are the wall layers from the ground up, each indexed by [x][y]
+	Levels [][][]int `json:"levels"`
+	// Floor is the optional floor texture number layer indexed by [x][y], 0 for the default
+	Floor [][]int `json:"floor"`
+	// Hazards is the optional layer of floor hazards indexed by [x][y], 0 for no hazard
+	Hazards [][]int `json:"hazards"`
+	// DefaultFloor is the floor texture number of positions without one in Floor, 0 for the built-in floor
+	DefaultFloor int `json:"defaultFloor"`
+	// Faces are the cells with different wall textures per face
+	Faces []mapFileFaces `json:"faces"`
+	// Doors are the ground level cells that can be opened, using the wall at the cell as the door texture
+	Doors []mapFileDoor `json:"doors"`
+	// Destructible are the wall cells worn down by projectiles, using the wall at the cell as the undamaged texture
+	Destructible []mapFileDestructible `json:"destructible"`
+	// Spawn is the player starting position
+	Spawn *mapFileSpawn `json:"spawn"`
+	// Sprites are the sprites placed on the map by their sprite type
+	Sprites []mapFileSprite `json:"sprites"`
+	// Triggers are the areas doing actions when entities enter, leave or stay in them
+	Triggers []mapFileTrigger `json:"triggers"`
+	// Environment is the optional sky, floor and lighting of the map
+	Environment *mapFileEnvironment `json:"environment"`
+}
+
+// mapFileEnvironment is the map environment with its light colors as [R, G, B] arrays
+type mapFileEnvironment struct {
+	Sky            string    `json:"sky"`
+	Floor          string    `json:"floor"`
+	RenderDistance *float64  `json:"renderDistance"`
+	Falloff        *float64  `json:"falloff"`
+	Illumination   *float64  `json:"illumination"`
+	MinLight       *[3]uint8 `json:"minLight"`
+	MaxLight       *[3]uint8 `json:"maxLight"`
+}
+
+// mapFileSpawn is the player spawn with its heading angle in degrees
+type mapFileSpawn struct {
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Angle float64 `json:"angle"`
+}
+
+// mapFileSprite is a sprite placement with its movement angle in degrees
+type mapFileSprite struct {
+	Type     string  `json:"type"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Z        float64 `json:"z"`
+	Angle    float64 `json:"angle"`
+	Velocity float64 `json:"velocity"`
+	Scale    float64 `json:"scale"`
+}
+
+type mapFileFaces struct {
+	Level int `json:"level"`
+	X     int `json:"x"`
+	Y     int `json:"y"`
+	North int `json:"north"`
+	South int `json:"south"`
+	East  int `json:"east"`
+	West  int `json:"west"`
+}
+
+// mapFileTrigger is a trigger area, the cell at X, Y if no size is given
+type mapFileTrigger struct {
+	X        float64                `json:"x"`
+	Y        float64                `json:"y"`
+	Width    *float64               `json:"width"`
+	Height   *float64               `json:"height"`
+	Event    TriggerEvent           `json:"event"`
+	Entities []TriggerEntity        `json:"entities"`
+	Once     bool                   `json:"once"`
+	Interval *float64               `json:"interval"`
+	Actions  []mapFileTriggerAction `json:"actions"`
+}
+
+// mapFileTriggerAction is a trigger action with its teleport angle in degrees
+type mapFileTriggerAction struct {
+	Type         TriggerActionType `json:"type"`
+	X            float64           `json:"x"`
+	Y            float64           `json:"y"`
+	Z            float64           `json:"z"`
+	Sprite       string            `json:"sprite"`
+	Effect       string            `json:"effect"`
+	Angle        *float64          `json:"angle"`
+	Illumination *float64          `json:"illumination"`
+	Falloff      *float64          `json:"falloff"`
+	Level        string            `json:"level"`
+}
+
+type mapFileDestructible struct {
+	Level  int     `json:"level"`
+	X      int     `json:"x"`
+	Y      int     `json:"y"`
+	Health float64 `json:"health"`
+	Stages []int   `json:"stages"`
+}
+
+type mapFileDoor struct {
+	X          int      `json:"x"`
+	Y          int      `json:"y"`
+	Locked     bool     `json:"locked"`
+	Auto       bool     `json:"auto"`
+	CloseDelay *float64 `json:"closeDelay"`
+}
+
+// LoadMap reads and validates the level file at the given path of the file system,
+// Tiled maps (.tmx/.tmj) are imported and any other file is read as a JSON level file
+func LoadMap(fsys fs.FS, filePath string) (*Map, error) {
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".tmx", ".tmj":
+		m, err := LoadTiledMap(fsys, filePath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Tiled map %s: %w", filePath, err)
+		}
+		return m, nil
+	}
+
+	data, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read level file: %w", err)
+	}
+
+	m, err := ParseMap(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid level file %s: %w", filePath, err)
+	}
+	return m, nil
+}
+
+// LoadMapFile reads and validates the level file at the given path of the operating system,
+// also returning the file system of the level so files it refers to can be loaded relative to it
+func LoadMapFile(filePath string) (*Map, fs.FS, error) {
+	mapFS, mapPath, err := MapFileFS(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m, err := LoadMap(mapFS, mapPath)
+	return m, mapFS, err
+}
+
+// MapFileFS returns the file system to load the level file at the given path of the operating system from,
+// and the path of the level file within it
+func MapFileFS(filePath string) (fs.FS, string, error) {
+	// use the root of the file system so files referred to by the level can be anywhere relative to it
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	root := filepath.VolumeName(absPath) + string(filepath.Separator)
+	return os.DirFS(root), filepath.ToSlash(strings.TrimPrefix(absPath, root)), nil
+}
+
+// ParseMap creates a map from the contents of a JSON level file
+func ParseMap(data []byte) (*Map, error) {
+	var mf mapFile
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&mf); err != nil {
+		return nil, err
+	}
+
+	if err := validateLevels(mf.Levels); err != nil {
+		return nil, err
+	}
+
+	if err := validateLayer("floor", mf.Floor, mf.Levels[0]); err != nil {
+		return nil, err
+	}
+	if err := validateHazards(mf.Hazards, mf.Levels[0]); err != nil {
+		return nil, err
+	}
+	if mf.DefaultFloor < 0 {
+		return nil, fmt.Errorf("negative default floor %d", mf.DefaultFloor)
+	}
+
+	m := NewMap(mf.Levels)
+	m.floor = mf.Floor
+	m.hazards = mf.Hazards
+	m.defaultFloor = mf.DefaultFloor
+
+	for _, ff := range mf.Faces {
+		if err := m.addFaces(MapCell{Level: ff.Level, X: ff.X, Y: ff.Y}, MapFaces{
+			FaceNorth: ff.North, FaceSouth: ff.South, FaceEast: ff.East, FaceWest: ff.West,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for _, fd := range mf.Doors {
+		door := NewDoor(fd.X, fd.Y, 0)
+		door.Locked = fd.Locked
+		door.Auto = fd.Auto
+		if fd.CloseDelay != nil {
+			door.CloseDelay = *fd.CloseDelay
+		}
+		m.doors = append(m.doors, door)
+	}
+
+	if err := m.initDoors(); err != nil {
+		return nil, err
+	}
+
+	for _, fd := range mf.Destructible {
+		cell := MapCell{Level: fd.Level, X: fd.X, Y: fd.Y}
+		m.destructibles = append(m.destructibles, NewDestructibleWall(cell, fd.Health, fd.Stages))
+	}
+	if err := m.initDestructibleWalls(); err != nil {
+		return nil, err
+	}
+
+	if mf.Spawn != nil {
+		m.spawn = &MapSpawn{X: mf.Spawn.X, Y: mf.Spawn.Y, Angle: geom.Radians(mf.Spawn.Angle)}
+	}
+	for _, sp := range mf.Sprites {
+		m.sprites = append(m.sprites, MapSprite{
+			Type: sp.Type, X: sp.X, Y: sp.Y, Z: sp.Z, Angle: geom.Radians(sp.Angle), Velocity: sp.Velocity, Scale: sp.Scale,
+		})
+	}
+
+	for _, ft := range mf.Triggers {
+		m.triggers = append(m.triggers, newMapFileTrigger(ft))
+	}
+
+	if mf.Environment != nil {
+		m.environment = newMapFileEnvironment(*mf.Environment)
+		if err := m.environment.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := m.validatePlacements(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// newMapFileTrigger creates a trigger from its level file declaration, using the default event and entities
+// of a trigger if none are given
+func newMapFileTrigger(ft mapFileTrigger) *Trigger {
+	width, height := 1.0, 1.0
+	if ft.Width != nil {
+		width = *ft.Width
+	}
+	if ft.Height != nil {
+		height = *ft.Height
+	}
+
+	t := NewTrigger(ft.X, ft.Y, width, height)
+	if ft.Event != "" {
+		t.Event = ft.Event
+	}
+	if ft.Entities != nil {
+		t.Entities = ft.Entities
+	}
+	t.Once = ft.Once
+	if ft.Interval != nil {
+		t.Interval = *ft.Interval
+	}
+	for _, fa := range ft.Actions {
+		var angle *float64
+		if fa.Angle != nil {
+			radians := geom.Radians(*fa.Angle)
+			angle = &radians
+		}
+		t.Actions = append(t.Actions, TriggerAction{
+			Type: fa.Type, X: fa.X, Y: fa.Y, Z: fa.Z, Angle: angle, Sprite: fa.Sprite, Effect: fa.Effect,
+			Illumination: fa.Illumination, Falloff: fa.Falloff, Level: fa.Level,
+		})
+	}
+	return t
+}
+
+// newMapFileEnvironment creates a map environment from its level file declaration
+func newMapFileEnvironment(fe mapFileEnvironment) Environment {
+	env := Environment{
+		Sky: fe.Sky, Floor: fe.Floor,
+		RenderDistance: fe.RenderDistance, LightFalloff: fe.Falloff, Illumination: fe.Illumination,
+	}
+	if fe.MinLight != nil {
+		env.MinLightRGB = &color.NRGBA{R: fe.MinLight[0], G: fe.MinLight[1], B: fe.MinLight[2], A: 255}
+	}
+	if fe.MaxLight != nil {
+		env.MaxLightRGB = &color.NRGBA{R: fe.MaxLight[0], G: fe.MaxLight[1], B: fe.MaxLight[2], A: 255}
+	}
+	return env
+}
+
+// validatePlacements makes sure the player spawn is on an open position of the map,
+// and that all sprites and triggers are placed within the map
+func (m *Map) validatePlacements() error {
+	worldMap := m.Level(0)
+	width, height := float64(len(worldMap)), float64(len(worldMap[0]))
+
+	if m.spawn == nil {
+		return fmt.Errorf("no player spawn declared")
+	}
+	if m.spawn.X < 0 || m.spawn.X >= width || m.spawn.Y < 0 || m.spawn.Y >= height {
+		return fmt.Errorf("player spawn at (%v, %v) is outside the map", m.spawn.X, m.spawn.Y)
+	}
+	if worldMap[int(m.spawn.X)][int(m.spawn.Y)] > 0 {
+		return fmt.Errorf("player spawn at (%v, %v) is inside a wall", m.spawn.X, m.spawn.Y)
+	}
+
+	for _, sprite := range m.sprites {
+		if sprite.Type == "" {
+			return fmt.Errorf("sprite at (%v, %v) has no type", sprite.X, sprite.Y)
+		}
+		if sprite.X < 0 || sprite.X >= width || sprite.Y < 0 || sprite.Y >= height {
+			return fmt.Errorf("%s sprite at (%v, %v) is outside the map", sprite.Type, sprite.X, sprite.Y)
+		}
+		if sprite.Scale < 0 {
+			return fmt.Errorf("%s sprite at (%v, %v) has negative scale %v", sprite.Type, sprite.X, sprite.Y, sprite.Scale)
+		}
+	}
+
+	for _, trigger := range m.triggers {
+		if err := trigger.validate(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateLayer makes sure an optional floor or hazards layer has the same size as the wall levels
+func validateLayer(name string, layer [][]int, worldMap [][]int) error {
+	if layer == nil {
+		return nil
+	}
+	if len(layer) != len(worldMap) {
+		return fmt.Errorf("%s has width %d, expected %d", name, len(layer), len(worldMap))
+	}
+	for x, column := range layer {
+		if len(column) != len(worldMap[x]) {
+			return fmt.Errorf("%s has height %d at x=%d, expected %d", name, len(column), x, len(worldMap[x]))
+		}
+		for y, value := range column {
+			if value < 0 {
+				return fmt.Errorf("%s has negative value %d at (%d, %d)", name, value, x, y)
+			}
+		}
+	}
+	return nil
+}
+
+// validateHazards makes sure an optional hazards layer has the same size as the wall levels and only known hazards
+func validateHazards(hazards [][]int, worldMap [][]int) error {
+	if err := validateLayer("hazards", hazards, worldMap); err != nil {
+		return err
+	}
+	for x, column := range hazards {
+		for y, value := range column {
+			if value >= int(numHazards) {
+				return fmt.Errorf("hazards has unknown hazard %d at (%d, %d)", value, x, y)
+			}
+		}
+	}
+	return nil
+}
+
+// addFaces sets the wall textures per face of a wall cell
+func (m *Map) addFaces(cell MapCell, faces MapFaces) error {
+	if layerValue(m.Level(cell.Level), cell.X, cell.Y) <= 0 {
+		return fmt.Errorf("faces at (%d, %d) of level %d are not on a wall", cell.X, cell.Y, cell.Level)
+	}
+	for _, value := range faces {
+		if value < 0 {
+			return fmt.Errorf("faces at (%d, %d) of level %d have negative value %d", cell.X, cell.Y, cell.Level, value)
+		}
+	}
+
+	if m.faces == nil {
+		m.faces = make(map[MapCell]MapFaces)
+	}
+	if _, ok := m.faces[cell]; ok {
+		return fmt.Errorf("faces at (%d, %d) of level %d declared more than once", cell.X, cell.Y, cell.Level)
+	}
+	m.faces[cell] = faces
+	return nil
+}
+
+// initDoors makes sure each door is on a ground level wall cell of its own, and uses the wall
+// at the cell as its door texture
+func (m *Map) initDoors() error {
+	worldMap := m.Level(0)
+	doorCells := make(map[image.Point]struct{}, len(m.doors))
+	for _, door := range m.doors {
+		if door.X < 0 || door.X >= len(worldMap) || door.Y < 0 || door.Y >= len(worldMap[0]) {
+			return fmt.Errorf("door at (%d, %d) is outside the map", door.X, door.Y)
+		}
+		if worldMap[door.X][door.Y] <= 0 {
+			return fmt.Errorf("door at (%d, %d) has no wall texture on level 0", door.X, door.Y)
+		}
+
+		cell := image.Pt(door.X, door.Y)
+		if _, ok := doorCells[cell]; ok {
+			return fmt.Errorf("more than one door at (%d, %d)", door.X, door.Y)
+		}
+		doorCells[cell] = struct{}{}
+
+		door.TexNum = worldMap[door.X][door.Y]
+	}
+	return nil
+}
+
+// validateLevels makes sure all levels are non-empty grids of the same size so that lookups
+// by map position cannot go out of bounds
+func validateLevels(levels [][][]int) error {
+	if len(levels) == 0 {
+		return fmt.Errorf("no levels defined")
+	}
+
+	width := len(levels[0])
+	if width == 0 || len(levels[0][0]) == 0 {
+		return fmt.Errorf("level 0 is empty")
+	}
+	height := len(levels[0][0])
+
+	for levelNum, level := range levels {
+		if len(level) != width {
+			return fmt.Errorf("level %d has width %d, expected %d", levelNum, len(level), width)
+		}
+		for x, column := range level {
+			if len(column) != height {
+				return fmt.Errorf("level %d has height %d at x=%d, expected %d", levelNum, len(column), x, height)
+			}
+			for y, value := range column {
+				if value < 0 {
+					return fmt.Errorf("level %d has negative value %d at (%d, %d)", levelNum, value, x, y)
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/harbdog/raycaster-go/geom"
+)
+
+// testRoom is a 4x4 level of walls around an open 2x2 room, indexed by [x][y]
+const testRoom = `[[1,1,1,1],[1,0,0,1],[1,0,0,1],[1,1,1,1]]`
+
+func TestParseMap(t *testing.T) {
+	m, err := ParseMap([]byte(`{
+		"levels": [` + testRoom + `, [[0,0,0,0],[0,2,0,0],[0,0,0,0],[0,0,0,0]]],
+		"floor": [[0,0,0,0],[0,3,3,0],[0,3,3,0],[0,0,0,0]],
+		"defaultFloor": 4,
+		"faces": [{"level": 0, "x": 0, "y": 1, "east": 5}],
+		"doors": [{"x": 3, "y": 1, "locked": true, "closeDelay": 2}],
+		"spawn": {"x": 1.5, "y": 2.5, "angle": 90},
+		"sprites": [{"type": "bat", "x": 2.5, "y": 1.5, "z": 0.5, "scale": 0.5}],
+		"triggers": [{"x": 2, "y": 2, "actions": [{"type": "end"}]}],
+		"environment": {"sky": "night", "minLight": [10, 20, 30]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.NumLevels() != 2 {
+		t.Errorf("NumLevels() = %d, want 2", m.NumLevels())
+	}
+	if m.Level(2) != nil {
+		t.Errorf("Level(2) = %v, want nil above the highest level", m.Level(2))
+	}
+	if got := m.Level(0)[0][1]; got != 1 {
+		t.Errorf("Level(0)[0][1] = %d, want 1", got)
+	}
+	if got := m.Level(1)[1][1]; got != 2 {
+		t.Errorf("Level(1)[1][1] = %d, want 2", got)
+	}
+	if got := m.FloorAt(1, 1); got != 3 {
+		t.Errorf("FloorAt(1, 1) = %d, want 3", got)
+	}
+	if got := m.FloorAt(0, 0); got != 4 {
+		t.Errorf("FloorAt(0, 0) = %d, want the default floor 4", got)
+	}
+	if got := m.WallAt(0, 0, 1, FaceEast); got != 5 {
+		t.Errorf("WallAt(0, 0, 1, FaceEast) = %d, want 5", got)
+	}
+	if got := m.WallAt(0, 0, 1, FaceWest); got != 1 {
+		t.Errorf("WallAt(0, 0, 1, FaceWest) = %d, want the cell texture 1", got)
+	}
+
+	door := m.DoorAt(3, 1)
+	if door == nil || !door.Locked || door.CloseDelay != 2 || door.TexNum != 1 {
+		t.Errorf("DoorAt(3, 1) = %+v, want a locked door with close delay 2 and texture 1", door)
+	}
+
+	if spawn := m.Spawn(); spawn.X != 1.5 || spawn.Y != 2.5 || spawn.Angle != geom.Radians(90) {
+		t.Errorf("Spawn() = %+v, want (1.5, 2.5) facing 90 degrees", spawn)
+	}
+	if sprites := m.Sprites(); len(sprites) != 1 || sprites[0].Type != "bat" || sprites[0].Z != 0.5 || sprites[0].Scale != 0.5 {
+		t.Errorf("Sprites() = %+v, want a bat at z 0.5 with scale 0.5", sprites)
+	}
+
+	triggers := m.Triggers()
+	if len(triggers) != 1 {
+		t.Fatalf("Triggers() has %d triggers, want 1", len(triggers))
+	}
+	if tr := triggers[0]; tr.Width != 1 || tr.Height != 1 || tr.Event != TriggerEnter || !tr.FiresFor(TriggerPlayer) {
+		t.Errorf("trigger = %+v, want the default size, event and entities", tr)
+	}
+
+	env := m.Environment()
+	if env.Sky != "night" || env.MinLightRGB == nil || env.MinLightRGB.B != 30 || env.RenderDistance != nil {
+		t.Errorf("Environment() = %+v, want sky night and min light (10, 20, 30) only", env)
+	}
+}
+
+func TestParseMapErrors(t *testing.T) {
+	const spawn = `"spawn": {"x": 1.5, "y": 1.5}`
+
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"unknown field", `{"levels": [` + testRoom + `], "walls": [], ` + spawn + `}`, `unknown field "walls"`},
+		{"no levels", `{"levels": [], ` + spawn + `}`, "no levels defined"},
+		{"empty level", `{"levels": [[]], ` + spawn + `}`, "level 0 is empty"},
+		{"level width", `{"levels": [` + testRoom + `, [[0,0,0,0]]], ` + spawn + `}`, "level 1 has width 1, expected 4"},
+		{"level height", `{"levels": [[[1,1,1,1],[1,0,0],[1,0,0,1],[1,1,1,1]]], ` + spawn + `}`, "level 0 has height 3 at x=1, expected 4"},
+		{"negative wall", `{"levels": [[[1,1,1,1],[1,0,-1,1],[1,0,0,1],[1,1,1,1]]], ` + spawn + `}`, "level 0 has negative value -1 at (1, 2)"},
+		{"floor size", `{"levels": [` + testRoom + `], "floor": [[0,0,0,0]], ` + spawn + `}`, "floor has width 1, expected 4"},
+		{"unknown hazard", `{"levels": [` + testRoom + `], "hazards": [[0,0,0,0],[0,0,0,0],[0,0,99,0],[0,0,0,0]], ` + spawn + `}`, "hazards has unknown hazard 99 at (2, 2)"},
+		{"default floor", `{"levels": [` + testRoom + `], "defaultFloor": -1, ` + spawn + `}`, "negative default floor -1"},
+		{"no spawn", `{"levels": [` + testRoom + `]}`, "no player spawn declared"},
+		{"spawn outside", `{"levels": [` + testRoom + `], "spawn": {"x": 4.5, "y": 1.5}}`, "player spawn at (4.5, 1.5) is outside the map"},
+		{"spawn in wall", `{"levels": [` + testRoom + `], "spawn": {"x": 0.5, "y": 0.5}}`, "player spawn at (0.5, 0.5) is inside a wall"},
+		{"sprite type", `{"levels": [` + testRoom + `], ` + spawn + `, "sprites": [{"x": 2, "y": 2}]}`, "sprite at (2, 2) has no type"},
+		{"sprite outside", `{"levels": [` + testRoom + `], ` + spawn + `, "sprites": [{"type": "bat", "x": -1, "y": 2}]}`, "bat sprite at (-1, 2) is outside the map"},
+		{"sprite scale", `{"levels": [` + testRoom + `], ` + spawn + `, "sprites": [{"type": "bat", "x": 2, "y": 2, "scale": -1}]}`, "bat sprite at (2, 2) has negative scale -1"},
+		{"faces off wall", `{"levels": [` + testRoom + `], ` + spawn + `, "faces": [{"x": 1, "y": 1, "north": 2}]}`, "faces at (1, 1) of level 0 are not on a wall"},
+		{"faces twice", `{"levels": [` + testRoom + `], ` + spawn + `, "faces": [{"x": 0, "y": 1, "north": 2}, {"x": 0, "y": 1, "south": 2}]}`, "faces at (0, 1) of level 0 declared more than once"},
+		{"door off wall", `{"levels": [` + testRoom + `], ` + spawn + `, "doors": [{"x": 2, "y": 2}]}`, "door at (2, 2) has no wall texture on level 0"},
+		{"door outside", `{"levels": [` + testRoom + `], ` + spawn + `, "doors": [{"x": 5, "y": 2}]}`, "door at (5, 2) is outside the map"},
+		{"door twice", `{"levels": [` + testRoom + `], ` + spawn + `, "doors": [{"x": 0, "y": 2}, {"x": 0, "y": 2}]}`, "more than one door at (0, 2)"},
+		{"render distance", `{"levels": [` + testRoom + `], ` + spawn + `, "environment": {"renderDistance": 0}}`, "invalid render distance 0"},
+		{"trigger outside", `{"levels": [` + testRoom + `], ` + spawn + `, "triggers": [{"x": 3, "y": 3, "width": 2, "actions": [{"type": "end"}]}]}`, "trigger at (3, 3) is outside the map"},
+		{"trigger actions", `{"levels": [` + testRoom + `], ` + spawn + `, "triggers": [{"x": 1, "y": 1}]}`, "trigger at (1, 1) has no actions"},
+		{"trigger interval", `{"levels": [` + testRoom + `], ` + spawn + `, "triggers": [{"x": 1, "y": 1, "event": "stay", "interval": -0.5, "actions": [{"type": "end"}]}]}`, "trigger at (1, 1) has negative interval -0.5"},
+		{"trigger door", `{"levels": [` + testRoom + `], ` + spawn + `, "triggers": [{"x": 1, "y": 1, "actions": [{"type": "door", "x": 2, "y": 2}]}]}`, "door action at (2, 2) without a door"},
+		{"teleport into wall", `{"levels": [` + testRoom + `], ` + spawn + `, "triggers": [{"x": 1, "y": 1, "actions": [{"type": "teleport", "x": 0.5, "y": 1.5}]}]}`, "teleport action to (0.5, 1.5) inside a wall on level 0"},
+		{
+			"teleport without headroom",
+			`{"levels": [` + testRoom + `, [[0,0,0,0],[0,0,0,0],[0,0,7,0],[0,0,0,0]]], ` + spawn + `, "triggers": [{"x": 1, "y": 1, "actions": [{"type": "teleport", "x": 2.5, "y": 2.5, "z": 0.75}]}]}`,
+			"teleport action to (2.5, 2.5) inside a wall on level 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMap([]byte(tt.json))
+			if err == nil {
+				t.Fatalf("ParseMap() succeeded, want error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseMap() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"github.com/harbdog/raycaster-go/geom"
 )
 
+// PlayerHeight is the collision height of the player, from its Z-position up
+const PlayerHeight = 0.5
+
 type Player struct {
 	*Entity
 	CameraZ    float64
@@ -24,6 +27,8 @@ func NewPlayer(x, y, angle, pitch float64) *Player {
 			Pitch:     pitch,
 			Velocity:  0,
 			MapColor:  color.RGBA{255, 0, 0, 255},
+			Health:    100,
+			MaxHealth: 100,
 		},
 		CameraZ:   0.5,
 		Moved:     false,
@@ -39,7 +39,7 @@ func NewProjectile(
 }
 
 func NewAnimatedProjectile(
-	x, y, scale float64, animationRate int, img *ebiten.Image, mapColor color.RGBA, columns, rows int,
+	x, y, scale, animationRate float64, img *ebiten.Image, mapColor color.RGBA, columns, rows int,
 	anchor raycaster.SpriteAnchor, collisionRadius, collisionHeight float64,
 ) *Projectile {
 	p := &Projectile{
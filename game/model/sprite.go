@@ -17,19 +17,28 @@ import (
 type Sprite struct {
 	*Entity
 	W, H           int
-	AnimationRate  int
+	AnimationRate  float64
 	Focusable      bool
 	illumination   float64
 	animReversed   bool
-	animCounter    int
+	animTime       float64
 	loopCounter    int
 	columns, rows  int
 	texNum, lenTex int
 	texFacingMap   map[float64]int
 	texFacingKeys  []float64
+	clips          map[string]*AnimationClip
+	clipName       string
+	clipFrame      int
+	clipFinished   bool
+	transitions    map[string][]string
 	texRects       []image.Rectangle
 	textures       []*ebiten.Image
+	frameDurations []float64
 	screenRect     *image.Rectangle
+
+	// OnAnimationFinished is called when the clip being played ends, after showing its last frame
+	OnAnimationFinished func(s *Sprite, clip string)
 }
 
 func (s *Sprite) Scale() float64 {
@@ -80,6 +89,7 @@ func NewSprite(
 	}
 
 	s.texNum = 0
+	s.columns, s.rows = 1, 1
 	s.lenTex = 1
 	s.textures = make([]*ebiten.Image, s.lenTex)
 
@@ -139,7 +149,7 @@ func NewSpriteFromSheet(
 }
 
 func NewAnimatedSprite(
-	x, y, scale float64, animationRate int, img *ebiten.Image, mapColor color.RGBA,
+	x, y, scale, animationRate float64, img *ebiten.Image, mapColor color.RGBA,
 	columns, rows int, anchor raycaster.SpriteAnchor, collisionRadius, collisionHeight float64,
 ) *Sprite {
 	s := &Sprite{
@@ -158,7 +168,7 @@ func NewAnimatedSprite(
 	}
 
 	s.AnimationRate = animationRate
-	s.animCounter = 0
+	s.animTime = 0
 	s.loopCounter = 0
 
 	s.texNum = 0
@@ -189,11 +199,97 @@ func NewAnimatedSprite(
 	return s
 }
 
+// NewSpriteFromSpriteSheet creates an animated sprite with the frames of a sprite sheet image exported
+// with its JSON frame layout (see LoadSpriteSheet), playing the tags of the sheet as animation clips.
+// The animation rate is used for frames without a duration. Frames are placed so their pivots line up
+// at the horizontal center of the sprite, trimmed frames and frames with different pivots are copied
+// into images of their own to do so.
+func NewSpriteFromSpriteSheet(
+	x, y, scale, animationRate float64, img *ebiten.Image, sheet *SpriteSheet, mapColor color.RGBA,
+	anchor raycaster.SpriteAnchor, collisionRadius, collisionHeight float64,
+) *Sprite {
+	s := &Sprite{
+		Entity: &Entity{
+			Position:        &geom.Vector2{X: x, Y: y},
+			PositionZ:       0,
+			Scale:           scale,
+			Anchor:          anchor,
+			Angle:           0,
+			Velocity:        0,
+			CollisionRadius: collisionRadius,
+			CollisionHeight: collisionHeight,
+			MapColor:        mapColor,
+		},
+		Focusable: true,
+	}
+
+	s.AnimationRate = animationRate
+	s.texNum = 0
+	s.columns, s.rows = len(sheet.Frames), 1
+	s.lenTex = len(sheet.Frames)
+	s.textures = make([]*ebiten.Image, s.lenTex)
+	s.texRects = make([]image.Rectangle, s.lenTex)
+	s.frameDurations = make([]float64, s.lenTex)
+
+	// size the frames to fit all frames with their pivots at the same point
+	var left, right, top, bottom int
+	for _, frame := range sheet.Frames {
+		pivotX, pivotY := frame.pivot()
+		left, right = maxInt(left, pivotX), maxInt(right, frame.Size.X-pivotX)
+		top, bottom = maxInt(top, pivotY), maxInt(bottom, frame.Size.Y-pivotY)
+	}
+	s.W, s.H = 2*maxInt(left, right), top+bottom
+
+	for i, frame := range sheet.Frames {
+		pivotX, pivotY := frame.pivot()
+		offset := image.Pt(s.W/2-pivotX, top-pivotY).Add(frame.Offset)
+		frameImg := img.SubImage(frame.Rect).(*ebiten.Image)
+
+		if offset == (image.Point{}) && frame.Rect.Dx() == s.W && frame.Rect.Dy() == s.H {
+			// frames already in place are used from the sheet image
+			s.textures[i] = frameImg
+			s.texRects[i] = frame.Rect
+		} else {
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(float64(offset.X), float64(offset.Y))
+			s.textures[i] = ebiten.NewImage(s.W, s.H)
+			s.textures[i].DrawImage(frameImg, op)
+			s.texRects[i] = image.Rect(0, 0, s.W, s.H)
+		}
+
+		s.frameDurations[i] = float64(frame.Duration) / 1000
+	}
+
+	for _, tag := range sheet.Tags {
+		s.AddAnimationClip(tag.Name, tag.Clip())
+	}
+
+	return s
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// frameDuration returns the number of seconds to show the texture for, 0 if the sprite is not animated
+func (s *Sprite) frameDuration(texNum int) float64 {
+	if texNum < len(s.frameDurations) && s.frameDurations[texNum] > 0 {
+		return s.frameDurations[texNum]
+	}
+	if s.AnimationRate > 0 {
+		return 1 / s.AnimationRate
+	}
+	return 0
+}
+
 func (s *Sprite) SetTextureFacingMap(texFacingMap map[float64]int) {
 	s.texFacingMap = texFacingMap
 
 	// create pre-sorted list of keys used during facing determination
-	s.texFacingKeys = make([]float64, len(texFacingMap))
+	s.texFacingKeys = make([]float64, 0, len(texFacingMap))
 	for k := range texFacingMap {
 		s.texFacingKeys = append(s.texFacingKeys, k)
 	}
@@ -227,7 +323,7 @@ func (s *Sprite) SetAnimationFrame(texNum int) {
 }
 
 func (s *Sprite) ResetAnimation() {
-	s.animCounter = 0
+	s.animTime = 0
 	s.loopCounter = 0
 	s.texNum = 0
 }
@@ -240,56 +336,81 @@ func (s *Sprite) ScreenRect() *image.Rectangle {
 	return s.screenRect
 }
 
-func (s *Sprite) Update(camPos *geom.Vector2) {
-	if s.AnimationRate <= 0 {
+// Update advances the animation by the number of seconds elapsed since the last update,
+// showing each frame for its duration in the sprite sheet or for 1/AnimationRate seconds
+func (s *Sprite) Update(camPos *geom.Vector2, dt float64) {
+	texRow, hasFacing := s.facingRow(camPos)
+	if clip, ok := s.clips[s.clipName]; ok {
+		s.updateClip(clip, texRow, dt)
 		return
 	}
 
-	if s.animCounter >= s.AnimationRate {
-		minTexNum := 0
-		maxTexNum := s.lenTex - 1
-
-		if len(s.texFacingMap) > 1 && camPos != nil {
-			// TODO: may want to be able to change facing even between animation frame changes
-
-			// use facing from camera position to determine min/max texNum in texFacingMap
-			// to update facing of sprite relative to camera and sprite angle
-			texRow := 0
-
-			// calculate angle from sprite relative to camera position by getting angle of line between them
-			lineToCam := geom.Line{X1: s.Position.X, Y1: s.Position.Y, X2: camPos.X, Y2: camPos.Y}
-			facingAngle := lineToCam.Angle() - s.Angle
-			if facingAngle < 0 {
-				// convert to positive angle needed to determine facing index to use
-				facingAngle += geom.Pi2
-			}
-			facingKeyAngle := s.getTextureFacingKeyForAngle(facingAngle)
-			if texFacingValue, ok := s.texFacingMap[facingKeyAngle]; ok {
-				texRow = texFacingValue
-			}
-
-			minTexNum = texRow * s.columns
-			maxTexNum = texRow*s.columns + s.columns - 1
-		}
+	if hasFacing {
+		// turn to the row facing the camera every update, keeping the frame within the row
+		s.texNum = texRow*s.columns + s.texNum%s.columns
+	}
+
+	duration := s.frameDuration(s.texNum)
+	if duration <= 0 {
+		return
+	}
 
-		s.animCounter = 0
+	s.animTime += dt
+	for duration > 0 && s.animTime >= duration {
+		s.animTime -= duration
+		s.nextFrame(texRow, hasFacing)
+		duration = s.frameDuration(s.texNum)
+	}
+}
 
-		if s.animReversed {
-			s.texNum -= 1
-			if s.texNum > maxTexNum || s.texNum < minTexNum {
-				s.texNum = maxTexNum
-				s.loopCounter++
-			}
-		} else {
-			s.texNum += 1
-			if s.texNum > maxTexNum || s.texNum < minTexNum {
-				s.texNum = minTexNum
-				s.loopCounter++
-			}
+// nextFrame moves to the next texture of the sprite sheet, or of the texture row when it has facings
+func (s *Sprite) nextFrame(texRow int, hasFacing bool) {
+	minTexNum := 0
+	maxTexNum := s.lenTex - 1
+
+	if hasFacing {
+		minTexNum = texRow * s.columns
+		maxTexNum = texRow*s.columns + s.columns - 1
+	}
+
+	if s.animReversed {
+		s.texNum -= 1
+		if s.texNum > maxTexNum || s.texNum < minTexNum {
+			s.texNum = maxTexNum
+			s.loopCounter++
 		}
 	} else {
-		s.animCounter++
+		s.texNum += 1
+		if s.texNum > maxTexNum || s.texNum < minTexNum {
+			s.texNum = minTexNum
+			s.loopCounter++
+		}
+	}
+}
+
+// facingRow returns the texture row of the sprite sheet facing the camera position,
+// false if the sprite does not have more than one facing row in its sprite sheet
+func (s *Sprite) facingRow(camPos *geom.Vector2) (int, bool) {
+	if len(s.texFacingMap) <= 1 || s.rows <= 1 || camPos == nil {
+		return 0, false
+	}
+
+	// use facing from camera position to determine the texture row in texFacingMap
+	// to update facing of sprite relative to camera and sprite angle
+	texRow := 0
+
+	// calculate angle from sprite relative to camera position by getting angle of line between them
+	lineToCam := geom.Line{X1: s.Position.X, Y1: s.Position.Y, X2: camPos.X, Y2: camPos.Y}
+	facingAngle := lineToCam.Angle() - s.Angle
+	if facingAngle < 0 {
+		// convert to positive angle needed to determine facing index to use
+		facingAngle += geom.Pi2
+	}
+	facingKeyAngle := s.getTextureFacingKeyForAngle(facingAngle)
+	if texFacingValue, ok := s.texFacingMap[facingKeyAngle]; ok && texFacingValue < s.rows {
+		texRow = texFacingValue
 	}
+	return texRow, true
 }
 
 func (s *Sprite) AddDebugLines(lineWidth int, clr color.Color) {
@@ -0,0 +1,174 @@
+package model
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+
+	"github.com/harbdog/raycaster-go"
+	"github.com/harbdog/raycaster-go/geom"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// TextureSource looks up the textures of the texture manifest by name
+type TextureSource interface {
+	// TextureByName returns the texture with the given name (nil if there is none)
+	TextureByName(name string) *ebiten.Image
+	// SpriteSheetByName returns the frame layout of the sheet texture with the given name (nil if it has none)
+	SpriteSheetByName(name string) *SpriteSheet
+}
+
+// SpriteFactory creates the sprites of the archetypes by name
+type SpriteFactory struct {
+	archetypes map[string]*spriteArchetype
+}
+
+// spriteArchetype is an archetype with its texture looked up
+type spriteArchetype struct {
+	Archetype
+	img      *ebiten.Image
+	sheet    *SpriteSheet
+	anchor   raycaster.SpriteAnchor
+	mapColor color.RGBA
+	facing   map[float64]int
+}
+
+// NewSpriteFactory creates a factory for the archetypes of the manifest, looking up their textures
+// and making sure their clips fit the frames of the textures
+func NewSpriteFactory(am *ArchetypeManifest, textures TextureSource) (*SpriteFactory, error) {
+	f := &SpriteFactory{archetypes: make(map[string]*spriteArchetype, len(am.Archetypes))}
+	for _, a := range am.Archetypes {
+		sa := &spriteArchetype{
+			Archetype: a,
+			img:       textures.TextureByName(a.Texture),
+			sheet:     textures.SpriteSheetByName(a.Texture),
+			mapColor:  color.RGBA{a.MapColor[0], a.MapColor[1], a.MapColor[2], a.MapColor[3]},
+		}
+		if sa.img == nil {
+			return nil, fmt.Errorf("archetype %q texture %q is not declared in the texture manifest", a.Name, a.Texture)
+		}
+		if sa.sheet != nil && (a.Columns != 0 || a.Rows != 0 || len(a.Facing) > 0) {
+			return nil, fmt.Errorf("archetype %q cannot have columns, rows or facing with sprite sheet data", a.Name)
+		}
+		sa.anchor, _ = a.anchor()
+
+		if len(a.Facing) > 0 {
+			sa.facing = make(map[float64]int, len(a.Facing))
+			for row, angle := range a.Facing {
+				sa.facing[geom.Radians(angle)] = row
+			}
+		}
+
+		if err := sa.validateClips(); err != nil {
+			return nil, err
+		}
+		f.archetypes[a.Name] = sa
+	}
+	return f, nil
+}
+
+// validateClips makes sure the clips are within the frames of a row of the texture,
+// and that the clips changed to are clips of the archetype or its sprite sheet data
+func (sa *spriteArchetype) validateClips() error {
+	clips := make(map[string]struct{})
+	numFrames := sa.numFrames()
+	if sa.sheet != nil {
+		for _, tag := range sa.sheet.Tags {
+			clips[tag.Name] = struct{}{}
+		}
+	}
+	for _, clip := range sa.Clips {
+		if clip.Last >= numFrames {
+			return fmt.Errorf("archetype %q clip %q has frame %d past its %d frames", sa.Name, clip.Name, clip.Last, numFrames)
+		}
+		clips[clip.Name] = struct{}{}
+	}
+
+	for _, clip := range sa.Clips {
+		if _, ok := clips[clip.Next]; clip.Next != "" && !ok {
+			return fmt.Errorf("archetype %q clip %q has unknown next clip %q", sa.Name, clip.Name, clip.Next)
+		}
+	}
+	for from, to := range sa.Transitions {
+		for _, name := range append([]string{from}, to...) {
+			if _, ok := clips[name]; !ok {
+				return fmt.Errorf("archetype %q has transitions for unknown clip %q", sa.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// numFrames returns the number of frames clips can use, those of a row for sheets with facing rows
+func (sa *spriteArchetype) numFrames() int {
+	if sa.sheet != nil {
+		return len(sa.sheet.Frames)
+	}
+	columns, rows := sa.SheetSize()
+	if len(sa.Facing) > 0 {
+		return columns
+	}
+	return columns * rows
+}
+
+// Has returns true if the factory has an archetype with the name
+func (f *SpriteFactory) Has(name string) bool {
+	_, ok := f.archetypes[name]
+	return ok
+}
+
+// Names returns the names of the archetypes in alphabetical order
+func (f *SpriteFactory) Names() []string {
+	names := make([]string, 0, len(f.archetypes))
+	for name := range f.archetypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewSprite creates a sprite of the named archetype at a map position, using the default scale of the archetype if scale is 0
+func (f *SpriteFactory) NewSprite(name string, x, y, scale float64) (*Sprite, error) {
+	sa, ok := f.archetypes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown sprite type %q", name)
+	}
+
+	if scale <= 0 {
+		scale = sa.Scale
+	}
+	if scale <= 0 {
+		scale = 1
+	}
+
+	var s *Sprite
+	columns, rows := sa.SheetSize()
+	switch {
+	case sa.sheet != nil:
+		s = NewSpriteFromSpriteSheet(x, y, scale, sa.Rate, sa.img, sa.sheet, sa.mapColor, sa.anchor, 0, 0)
+	case columns*rows > 1:
+		s = NewAnimatedSprite(x, y, scale, sa.Rate, sa.img, sa.mapColor, columns, rows, sa.anchor, 0, 0)
+	default:
+		s = NewSprite(x, y, scale, sa.img, sa.mapColor, sa.anchor, 0, 0)
+	}
+
+	// convert pixel to grid using frame pixel size
+	s.CollisionRadius = (scale * sa.CollisionRadius) / float64(s.W)
+	s.CollisionHeight = (scale * sa.CollisionHeight) / float64(s.H)
+	s.Velocity = sa.Velocity
+	s.Health, s.MaxHealth = sa.Health, sa.Health
+
+	s.SetAnimationReversed(sa.Reversed)
+	if sa.facing != nil {
+		s.SetTextureFacingMap(sa.facing)
+	}
+	for _, clip := range sa.Clips {
+		s.AddAnimationClip(clip.Name, clip.Clip())
+	}
+	for from, to := range sa.Transitions {
+		s.SetAnimationTransitions(from, to...)
+	}
+
+	return s, nil
+}
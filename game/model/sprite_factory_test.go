@@ -0,0 +1,175 @@
+package model
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/harbdog/raycaster-go"
+	"github.com/harbdog/raycaster-go/geom"
+)
+
+// testTextures is a texture source of the textures and sprite sheets given by name
+type testTextures struct {
+	textures map[string]*ebiten.Image
+	sheets   map[string]*SpriteSheet
+}
+
+func (tt testTextures) TextureByName(name string) *ebiten.Image {
+	return tt.textures[name]
+}
+
+func (tt testTextures) SpriteSheetByName(name string) *SpriteSheet {
+	return tt.sheets[name]
+}
+
+func newTestTextures() testTextures {
+	return testTextures{
+		textures: map[string]*ebiten.Image{
+			"bat_sheet":   ebiten.NewImage(96, 128),
+			"large_rock":  ebiten.NewImage(64, 32),
+			"slime_sheet": ebiten.NewImage(64, 32),
+		},
+		sheets: map[string]*SpriteSheet{
+			"slime_sheet": {
+				Frames: []SheetFrame{
+					{Rect: image.Rect(0, 0, 32, 32), Size: image.Pt(32, 32)},
+					{Rect: image.Rect(32, 0, 64, 32), Size: image.Pt(32, 32)},
+				},
+				Tags: []SheetTag{{Name: "bounce", From: 0, To: 1, Loop: true}},
+			},
+		},
+	}
+}
+
+func TestSpriteFactoryNewSprite(t *testing.T) {
+	am, err := ParseArchetypes([]byte(`{
+		"archetypes": [
+			{
+				"name": "bat", "texture": "bat_sheet", "columns": 3, "rows": 4, "scale": 0.25, "anchor": "top",
+				"collisionRadius": 16, "collisionHeight": 24, "rate": 5.5, "facing": [0, 270, 180, 90],
+				"velocity": 0.03, "health": 10,
+				"clips": [{"name": "fly", "last": 2, "loop": true}, {"name": "die", "first": 1, "last": 2}],
+				"transitions": {"die": []}
+			},
+			{"name": "rock", "texture": "large_rock"},
+			{"name": "slime", "texture": "slime_sheet", "clips": [{"name": "squish", "first": 1, "last": 1, "next": "bounce"}]}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseArchetypes() error = %v", err)
+	}
+	f, err := NewSpriteFactory(am, newTestTextures())
+	if err != nil {
+		t.Fatalf("NewSpriteFactory() error = %v", err)
+	}
+
+	if names := f.Names(); strings.Join(names, ",") != "bat,rock,slime" {
+		t.Errorf("Names() = %v, want [bat rock slime]", names)
+	}
+	if !f.Has("rock") || f.Has("tree") {
+		t.Error("Has() should be true for rock and false for tree")
+	}
+	if _, err := f.NewSprite("tree", 1, 1, 0); err == nil || !strings.Contains(err.Error(), `unknown sprite type "tree"`) {
+		t.Errorf("NewSprite(tree) error = %v, want unknown sprite type", err)
+	}
+
+	bat, err := f.NewSprite("bat", 2, 3, 0)
+	if err != nil {
+		t.Fatalf("NewSprite(bat) error = %v", err)
+	}
+	// 32x32 frames at the default scale of the archetype
+	if bat.Scale() != 0.25 || bat.Anchor != raycaster.AnchorTop || bat.Position.X != 2 || bat.Position.Y != 3 {
+		t.Errorf("bat scale %v, anchor %v at %v, want scale 0.25 with top anchor at (2, 3)", bat.Scale(), bat.Anchor, bat.Position)
+	}
+	if bat.CollisionRadius != 0.125 || bat.CollisionHeight != 0.1875 {
+		t.Errorf("bat collision %v x %v, want 0.125 x 0.1875", bat.CollisionRadius, bat.CollisionHeight)
+	}
+	if bat.Velocity != 0.03 || bat.Health != 10 || bat.MaxHealth != 10 {
+		t.Errorf("bat velocity %v with health %v/%v, want 0.03 with health 10/10", bat.Velocity, bat.Health, bat.MaxHealth)
+	}
+	if bat.texFacingMap[geom.Radians(270)] != 1 || len(bat.texFacingKeys) != 4 {
+		t.Errorf("bat facing map = %v, want 4 facing rows with 270 degrees on row 1", bat.texFacingMap)
+	}
+	if bat.AnimationClip() != "fly" || !bat.HasAnimationClip("die") {
+		t.Errorf("bat playing clip %q, want fly with a die clip", bat.AnimationClip())
+	}
+	if !bat.PlayAnimation("die") || bat.PlayAnimation("fly") {
+		t.Error("bat should change to the die clip and not back since die is a final clip")
+	}
+
+	rock, err := f.NewSprite("rock", 1, 1, 2)
+	if err != nil {
+		t.Fatalf("NewSprite(rock) error = %v", err)
+	}
+	if rock.Scale() != 2 || rock.Anchor != raycaster.AnchorBottom || rock.lenTex != 1 || rock.CollisionRadius != 0 {
+		t.Errorf("rock scale %v, anchor %v with %d textures, want a single texture at scale 2 with bottom anchor",
+			rock.Scale(), rock.Anchor, rock.lenTex)
+	}
+
+	slime, err := f.NewSprite("slime", 1, 1, 0)
+	if err != nil {
+		t.Fatalf("NewSprite(slime) error = %v", err)
+	}
+	// the tags of the sheet are played first, before the clips of the archetype
+	if slime.lenTex != 2 || slime.Scale() != 1 || slime.AnimationClip() != "bounce" || !slime.HasAnimationClip("squish") {
+		t.Errorf("slime has %d textures at scale %v playing clip %q, want 2 textures at scale 1 playing bounce with a squish clip",
+			slime.lenTex, slime.Scale(), slime.AnimationClip())
+	}
+}
+
+func TestNewSpriteFactoryErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{
+			"unknown texture",
+			`{"archetypes": [{"name": "tree", "texture": "tree_09"}]}`,
+			`archetype "tree" texture "tree_09" is not declared in the texture manifest`,
+		},
+		{
+			"grid with sheet data",
+			`{"archetypes": [{"name": "slime", "texture": "slime_sheet", "columns": 2}]}`,
+			`archetype "slime" cannot have columns, rows or facing with sprite sheet data`,
+		},
+		{
+			"clip past frames",
+			`{"archetypes": [{"name": "rock", "texture": "large_rock", "columns": 2, "clips": [{"name": "idle", "last": 2}]}]}`,
+			`archetype "rock" clip "idle" has frame 2 past its 2 frames`,
+		},
+		{
+			"clip past facing row",
+			`{"archetypes": [{"name": "bat", "texture": "bat_sheet", "columns": 3, "rows": 4, "facing": [0, 270, 180, 90], "clips": [{"name": "fly", "last": 3}]}]}`,
+			`archetype "bat" clip "fly" has frame 3 past its 3 frames`,
+		},
+		{
+			"unknown next clip",
+			`{"archetypes": [{"name": "slime", "texture": "slime_sheet", "clips": [{"name": "squish", "next": "jump"}]}]}`,
+			`archetype "slime" clip "squish" has unknown next clip "jump"`,
+		},
+		{
+			"unknown transition",
+			`{"archetypes": [{"name": "slime", "texture": "slime_sheet", "transitions": {"bounce": ["jump"]}}]}`,
+			`archetype "slime" has transitions for unknown clip "jump"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			am, err := ParseArchetypes([]byte(tt.json))
+			if err != nil {
+				t.Fatalf("ParseArchetypes() error = %v", err)
+			}
+			_, err = NewSpriteFactory(am, newTestTextures())
+			if err == nil {
+				t.Fatalf("NewSpriteFactory() succeeded, want error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewSpriteFactory() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/fs"
+	"math"
+	"strconv"
+)
+
+// SpriteSheet is the frame layout of a sprite sheet image exported by Aseprite or TexturePacker
+// as JSON, with frames of any size anywhere in the image
+type SpriteSheet struct {
+	Frames []SheetFrame
+	Tags   []SheetTag
+}
+
+// SheetFrame is a frame of a sprite sheet image
+type SheetFrame struct {
+	// Rect is the area of the frame in the sheet image
+	Rect image.Rectangle
+	// Size is the size of the frame before it was trimmed, with Offset the position of Rect within it
+	Size   image.Point
+	Offset image.Point
+	// Duration is the number of milliseconds to show the frame (0 to use the animation rate of the sprite)
+	Duration int
+	// PivotX and PivotY are the point of the frame the sprite is positioned by, from 0 to 1 of its size
+	PivotX, PivotY float64
+}
+
+// pivot returns the pixel position of the pivot within the untrimmed frame
+func (f SheetFrame) pivot() (int, int) {
+	return int(math.Round(f.PivotX * float64(f.Size.X))), int(math.Round(f.PivotY * float64(f.Size.Y)))
+}
+
+// SheetTag is a named range of frames of a sprite sheet, used as an animation clip
+type SheetTag struct {
+	Name     string
+	From, To int
+	// Reverse plays the frames from last to first, and PingPong plays them forward then back
+	Reverse, PingPong bool
+	// Loop is false for tags played a single time
+	Loop bool
+}
+
+// Clip returns the animation clip playing the frames of the tag
+func (t SheetTag) Clip() AnimationClip {
+	return AnimationClip{First: t.From, Last: t.To, Loop: t.Loop, Reverse: t.Reverse, PingPong: t.PingPong}
+}
+
+// sheetFile is the JSON of the frames, tags and slices shared by Aseprite and TexturePacker exports
+type sheetFile struct {
+	Frames json.RawMessage `json:"frames"`
+	Meta   struct {
+		FrameTags []struct {
+			Name      string `json:"name"`
+			From      int    `json:"from"`
+			To        int    `json:"to"`
+			Direction string `json:"direction"`
+			Repeat    string `json:"repeat"`
+		} `json:"frameTags"`
+		Slices []struct {
+			Keys []struct {
+				Frame  int         `json:"frame"`
+				Bounds sheetRect   `json:"bounds"`
+				Pivot  *sheetPoint `json:"pivot"`
+			} `json:"keys"`
+		} `json:"slices"`
+	} `json:"meta"`
+}
+
+type sheetFrame struct {
+	Filename         string    `json:"filename"`
+	Frame            sheetRect `json:"frame"`
+	Rotated          bool      `json:"rotated"`
+	Trimmed          bool      `json:"trimmed"`
+	SpriteSourceSize sheetRect `json:"spriteSourceSize"`
+	SourceSize       sheetSize `json:"sourceSize"`
+	Duration         int       `json:"duration"`
+	Pivot            *struct {
+		X float64 `json:"x"`
+		Y float64 `json:"y"`
+	} `json:"pivot"`
+}
+
+type sheetRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type sheetSize struct {
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type sheetPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// LoadSpriteSheet reads the sprite sheet JSON export at the given path of the file system
+func LoadSpriteSheet(fsys fs.FS, filePath string) (*SpriteSheet, error) {
+	data, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read sprite sheet: %w", err)
+	}
+
+	sheet, err := ParseSpriteSheet(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sprite sheet %s: %w", filePath, err)
+	}
+	return sheet, nil
+}
+
+// ParseSpriteSheet creates a sprite sheet from the contents of an Aseprite or TexturePacker JSON export,
+// with the frames either as an array or as a hash by file name (kept in the order of the file).
+// Pivots are read from TexturePacker frames or from the first Aseprite slice with a pivot.
+func ParseSpriteSheet(data []byte) (*SpriteSheet, error) {
+	var sf sheetFile
+	if err := json.Unmarshal(data, &sf); err != nil {
+		return nil, err
+	}
+
+	frames, err := parseSheetFrames(sf.Frames)
+	if err != nil {
+		return nil, err
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("sprite sheet has no frames")
+	}
+
+	sheet := &SpriteSheet{Frames: make([]SheetFrame, len(frames))}
+	for i, f := range frames {
+		if f.Filename == "" {
+			f.Filename = strconv.Itoa(i)
+		}
+		if f.Rotated {
+			return nil, fmt.Errorf("frame %q is rotated, rotated frames are not supported", f.Filename)
+		}
+		if f.Frame.W <= 0 || f.Frame.H <= 0 {
+			return nil, fmt.Errorf("frame %q has no size", f.Filename)
+		}
+		if f.Duration < 0 {
+			return nil, fmt.Errorf("frame %q has a negative duration", f.Filename)
+		}
+
+		frame := SheetFrame{
+			Rect:     image.Rect(f.Frame.X, f.Frame.Y, f.Frame.X+f.Frame.W, f.Frame.Y+f.Frame.H),
+			Size:     image.Pt(f.Frame.W, f.Frame.H),
+			Duration: f.Duration,
+			PivotX:   0.5,
+			PivotY:   0.5,
+		}
+		if f.Trimmed {
+			frame.Size = image.Pt(f.SourceSize.W, f.SourceSize.H)
+			frame.Offset = image.Pt(f.SpriteSourceSize.X, f.SpriteSourceSize.Y)
+			trimmed := image.Rectangle{Min: frame.Offset, Max: frame.Offset.Add(frame.Rect.Size())}
+			if !trimmed.In(image.Rect(0, 0, frame.Size.X, frame.Size.Y)) {
+				return nil, fmt.Errorf("trimmed frame %q does not fit its source size", f.Filename)
+			}
+		}
+		if f.Pivot != nil {
+			frame.PivotX, frame.PivotY = f.Pivot.X, f.Pivot.Y
+		}
+		sheet.Frames[i] = frame
+	}
+
+	// Aseprite pivots are in pixels of the slice bounds, each key applying from its frame onward
+	for _, slice := range sf.Meta.Slices {
+		if len(slice.Keys) == 0 || slice.Keys[0].Pivot == nil {
+			continue
+		}
+		for k, key := range slice.Keys {
+			end := len(sheet.Frames)
+			if k+1 < len(slice.Keys) {
+				end = slice.Keys[k+1].Frame
+			}
+			for i := key.Frame; i >= 0 && i < end && i < len(sheet.Frames); i++ {
+				frame := &sheet.Frames[i]
+				frame.PivotX = float64(key.Bounds.X+key.Pivot.X) / float64(frame.Size.X)
+				frame.PivotY = float64(key.Bounds.Y+key.Pivot.Y) / float64(frame.Size.Y)
+			}
+		}
+		break
+	}
+
+	for _, t := range sf.Meta.FrameTags {
+		if t.Name == "" {
+			return nil, fmt.Errorf("frame tag from %d to %d has no name", t.From, t.To)
+		}
+		if t.From < 0 || t.To < t.From || t.To >= len(sheet.Frames) {
+			return nil, fmt.Errorf("frame tag %q has invalid frames %d to %d", t.Name, t.From, t.To)
+		}
+
+		tag := SheetTag{Name: t.Name, From: t.From, To: t.To, Loop: true}
+		switch t.Direction {
+		case "", "forward":
+		case "reverse":
+			tag.Reverse = true
+		case "pingpong":
+			tag.PingPong = true
+		case "pingpong_reverse":
+			tag.Reverse, tag.PingPong = true, true
+		default:
+			return nil, fmt.Errorf("frame tag %q has unknown direction %q", t.Name, t.Direction)
+		}
+		if t.Repeat != "" {
+			// tags repeating a number of times are played through once
+			repeat, err := strconv.Atoi(t.Repeat)
+			if err != nil || repeat < 0 {
+				return nil, fmt.Errorf("frame tag %q has invalid repeat %q", t.Name, t.Repeat)
+			}
+			tag.Loop = repeat == 0
+		}
+		sheet.Tags = append(sheet.Tags, tag)
+	}
+
+	return sheet, nil
+}
+
+// parseSheetFrames reads the frames from either an array of frames or a hash of frames by file name
+func parseSheetFrames(data json.RawMessage) ([]sheetFrame, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var frames []sheetFrame
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &frames); err != nil {
+			return nil, err
+		}
+		return frames, nil
+	}
+
+	// decode the hash a token at a time, since the order of its frames is the animation order
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if t, err := decoder.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("frames must be an array or an object")
+	}
+	for decoder.More() {
+		t, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var frame sheetFrame
+		if err := decoder.Decode(&frame); err != nil {
+			return nil, err
+		}
+		frame.Filename = t.(string)
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
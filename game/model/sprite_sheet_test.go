@@ -0,0 +1,134 @@
+package model
+
+import (
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestParseSpriteSheetAseprite(t *testing.T) {
+	// frames hashed by file name are kept in the order of the file, not sorted by name
+	sheet, err := ParseSpriteSheet([]byte(`{
+		"frames": {
+			"hero 2.aseprite": {"frame": {"x": 0, "y": 0, "w": 32, "h": 48}, "sourceSize": {"w": 32, "h": 48}, "duration": 100},
+			"hero 0.aseprite": {"frame": {"x": 32, "y": 0, "w": 32, "h": 48}, "sourceSize": {"w": 32, "h": 48}, "duration": 150},
+			"hero 1.aseprite": {"frame": {"x": 64, "y": 0, "w": 32, "h": 48}, "sourceSize": {"w": 32, "h": 48}, "duration": 200}
+		},
+		"meta": {
+			"frameTags": [
+				{"name": "walk", "from": 0, "to": 2, "direction": "pingpong"},
+				{"name": "hurt", "from": 1, "to": 1, "direction": "forward", "repeat": "1"},
+				{"name": "back", "from": 0, "to": 1, "direction": "reverse"}
+			],
+			"slices": [
+				{"name": "hitbox", "keys": [{"frame": 0, "bounds": {"x": 0, "y": 0, "w": 32, "h": 48}}]},
+				{"name": "pivot", "keys": [
+					{"frame": 0, "bounds": {"x": 8, "y": 0, "w": 16, "h": 48}, "pivot": {"x": 8, "y": 48}},
+					{"frame": 2, "bounds": {"x": 0, "y": 0, "w": 32, "h": 48}, "pivot": {"x": 8, "y": 24}}
+				]}
+			]
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantFrames := []SheetFrame{
+		{Rect: image.Rect(0, 0, 32, 48), Size: image.Pt(32, 48), Duration: 100, PivotX: 0.5, PivotY: 1},
+		{Rect: image.Rect(32, 0, 64, 48), Size: image.Pt(32, 48), Duration: 150, PivotX: 0.5, PivotY: 1},
+		{Rect: image.Rect(64, 0, 96, 48), Size: image.Pt(32, 48), Duration: 200, PivotX: 0.25, PivotY: 0.5},
+	}
+	if len(sheet.Frames) != len(wantFrames) {
+		t.Fatalf("Frames = %+v, want %+v", sheet.Frames, wantFrames)
+	}
+	for i := range wantFrames {
+		if sheet.Frames[i] != wantFrames[i] {
+			t.Errorf("Frames[%d] = %+v, want %+v", i, sheet.Frames[i], wantFrames[i])
+		}
+	}
+
+	wantTags := []SheetTag{
+		{Name: "walk", From: 0, To: 2, PingPong: true, Loop: true},
+		{Name: "hurt", From: 1, To: 1},
+		{Name: "back", From: 0, To: 1, Reverse: true, Loop: true},
+	}
+	if len(sheet.Tags) != len(wantTags) {
+		t.Fatalf("Tags = %+v, want %+v", sheet.Tags, wantTags)
+	}
+	for i := range wantTags {
+		if sheet.Tags[i] != wantTags[i] {
+			t.Errorf("Tags[%d] = %+v, want %+v", i, sheet.Tags[i], wantTags[i])
+		}
+	}
+}
+
+func TestParseSpriteSheetTexturePacker(t *testing.T) {
+	sheet, err := ParseSpriteSheet([]byte(`{
+		"frames": [
+			{
+				"filename": "bat_0.png", "frame": {"x": 2, "y": 2, "w": 20, "h": 10}, "rotated": false, "trimmed": true,
+				"spriteSourceSize": {"x": 6, "y": 12, "w": 20, "h": 10}, "sourceSize": {"w": 32, "h": 32},
+				"pivot": {"x": 0.5, "y": 0.25}
+			},
+			{
+				"filename": "bat_1.png", "frame": {"x": 24, "y": 2, "w": 32, "h": 32}, "rotated": false, "trimmed": false,
+				"spriteSourceSize": {"x": 0, "y": 0, "w": 32, "h": 32}, "sourceSize": {"w": 32, "h": 32}
+			}
+		],
+		"meta": {"app": "https://www.codeandweb.com/texturepacker", "size": {"w": 64, "h": 64}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantFrames := []SheetFrame{
+		{Rect: image.Rect(2, 2, 22, 12), Size: image.Pt(32, 32), Offset: image.Pt(6, 12), PivotX: 0.5, PivotY: 0.25},
+		{Rect: image.Rect(24, 2, 56, 34), Size: image.Pt(32, 32), PivotX: 0.5, PivotY: 0.5},
+	}
+	if len(sheet.Frames) != len(wantFrames) {
+		t.Fatalf("Frames = %+v, want %+v", sheet.Frames, wantFrames)
+	}
+	for i := range wantFrames {
+		if sheet.Frames[i] != wantFrames[i] {
+			t.Errorf("Frames[%d] = %+v, want %+v", i, sheet.Frames[i], wantFrames[i])
+		}
+	}
+	if len(sheet.Tags) != 0 {
+		t.Errorf("Tags = %+v, want none", sheet.Tags)
+	}
+}
+
+func TestParseSpriteSheetErrors(t *testing.T) {
+	const frame = `{"frame": {"x": 0, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}}`
+
+	tests := []struct {
+		name, json, want string
+	}{
+		{"no frames", `{"frames": [], "meta": {}}`, "sprite sheet has no frames"},
+		{"frames type", `{"frames": "walk.png"}`, "frames must be an array or an object"},
+		{"rotated", `{"frames": {"a.png": {"frame": {"x": 0, "y": 0, "w": 16, "h": 16}, "rotated": true}}}`, `frame "a.png" is rotated`},
+		{"no size", `{"frames": [{"frame": {"x": 0, "y": 0, "w": 0, "h": 16}}]}`, `frame "0" has no size`},
+		{"duration", `{"frames": [{"frame": {"x": 0, "y": 0, "w": 16, "h": 16}, "duration": -1}]}`, `frame "0" has a negative duration`},
+		{
+			"trimmed size",
+			`{"frames": [{"frame": {"x": 0, "y": 0, "w": 16, "h": 16}, "trimmed": true, "spriteSourceSize": {"x": 8, "y": 0}, "sourceSize": {"w": 16, "h": 16}}]}`,
+			`trimmed frame "0" does not fit its source size`,
+		},
+		{"tag name", `{"frames": [` + frame + `], "meta": {"frameTags": [{"from": 0, "to": 0}]}}`, "frame tag from 0 to 0 has no name"},
+		{"tag frames", `{"frames": [` + frame + `], "meta": {"frameTags": [{"name": "walk", "from": 0, "to": 1}]}}`, `frame tag "walk" has invalid frames 0 to 1`},
+		{"tag direction", `{"frames": [` + frame + `], "meta": {"frameTags": [{"name": "walk", "from": 0, "to": 0, "direction": "sideways"}]}}`, `frame tag "walk" has unknown direction "sideways"`},
+		{"tag repeat", `{"frames": [` + frame + `], "meta": {"frameTags": [{"name": "walk", "from": 0, "to": 0, "repeat": "twice"}]}}`, `frame tag "walk" has invalid repeat "twice"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSpriteSheet([]byte(tt.json))
+			if err == nil {
+				t.Fatalf("ParseSpriteSheet() succeeded, want error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseSpriteSheet() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
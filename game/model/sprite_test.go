@@ -0,0 +1,75 @@
+package model
+
+import (
+	"image/color"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/harbdog/raycaster-go"
+	"github.com/harbdog/raycaster-go/geom"
+)
+
+func TestSetTextureFacingMap(t *testing.T) {
+	s := NewSprite(1, 1, 1, ebiten.NewImage(16, 16), color.RGBA{}, raycaster.AnchorBottom, 0, 0)
+	s.SetTextureFacingMap(map[float64]int{math.Pi: 2, 0: 0, 3 * math.Pi / 2: 3, math.Pi / 2: 1})
+
+	want := []float64{0, math.Pi / 2, math.Pi, 3 * math.Pi / 2}
+	if !reflect.DeepEqual(s.texFacingKeys, want) {
+		t.Errorf("texFacingKeys = %v, want %v", s.texFacingKeys, want)
+	}
+	if got := s.getTextureFacingKeyForAngle(geom.Pi2 - 0.1); got != 0 {
+		t.Errorf("getTextureFacingKeyForAngle(2π-0.1) = %v, want the key 0 across the wrap around", got)
+	}
+}
+
+func TestSpriteUpdateFacing(t *testing.T) {
+	// the camera is west of the sprite, seeing the side of the sprite facing 180 degrees
+	camPos := &geom.Vector2{X: 0, Y: 1}
+	facing := map[float64]int{0: 0, math.Pi: 1}
+
+	tests := []struct {
+		name       string
+		sprite     func() *Sprite
+		texNum     int
+		wantTexNum int
+	}{
+		{
+			name: "single image",
+			sprite: func() *Sprite {
+				return NewSprite(1, 1, 1, ebiten.NewImage(16, 16), color.RGBA{}, raycaster.AnchorBottom, 0, 0)
+			},
+			wantTexNum: 0,
+		},
+		{
+			name: "row facing the camera",
+			sprite: func() *Sprite {
+				return NewSpriteFromSheet(1, 1, 1, ebiten.NewImage(32, 32), color.RGBA{}, 2, 2, 0, raycaster.AnchorBottom, 0, 0)
+			},
+			texNum:     1,
+			wantTexNum: 3,
+		},
+		{
+			name: "facing rows outside the sheet",
+			sprite: func() *Sprite {
+				return NewSpriteFromSheet(1, 1, 1, ebiten.NewImage(32, 16), color.RGBA{}, 2, 1, 0, raycaster.AnchorBottom, 0, 0)
+			},
+			texNum:     1,
+			wantTexNum: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.sprite()
+			s.SetTextureFacingMap(facing)
+			s.SetAnimationFrame(tt.texNum)
+
+			s.Update(camPos, 0.1)
+			if s.texNum != tt.wantTexNum {
+				t.Errorf("texNum = %d, want %d", s.texNum, tt.wantTexNum)
+			}
+		})
+	}
+}
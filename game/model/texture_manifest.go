@@ -0,0 +1,169 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// TextureKind is how a texture declared by the texture manifest is used
+type TextureKind string
+
+const (
+	// TextureWall textures are used by maps for walls (and floors) by their wall texture number
+	TextureWall TextureKind = "wall"
+	// TextureFloor textures are used for the floor of positions without a map floor texture
+	TextureFloor TextureKind = "floor"
+	TextureSky   TextureKind = "sky"
+	// TextureSprite textures are single images for sprites, weapons and effects
+	TextureSprite TextureKind = "sprite"
+	// TextureSheet textures are sprite sheets with multiple frames in columns and rows
+	TextureSheet TextureKind = "sheet"
+)
+
+// TextureManifest lists the textures to load by name (see "Texture manifest" in README.md)
+type TextureManifest struct {
+	Textures []TextureEntry `json:"textures"`
+}
+
+// TextureEntry is a texture declared by the texture manifest
+type TextureEntry struct {
+	Name string `json:"name"`
+	// File is the path of the image file, relative to the manifest
+	File string      `json:"file"`
+	Kind TextureKind `json:"kind"`
+	// ID is the wall texture number used by maps, for wall textures only
+	ID int `json:"id"`
+	// Columns and Rows make a wall texture an animated sprite sheet of frames (read row by row),
+	// showing Rate frames per second. Frames is the number of frames if the last row is not full.
+	Columns int     `json:"columns"`
+	Rows    int     `json:"rows"`
+	Frames  int     `json:"frames"`
+	Rate    float64 `json:"rate"`
+	// Data is the path of the Aseprite or TexturePacker JSON export with the frame layout of a sheet texture,
+	// relative to the manifest
+	Data string `json:"data"`
+}
+
+// IsAnimated returns true if the texture is a sprite sheet of animation frames
+func (e TextureEntry) IsAnimated() bool {
+	return e.NumFrames() > 1
+}
+
+// NumFrames returns the number of animation frames of the texture, 1 if it is not animated
+func (e TextureEntry) NumFrames() int {
+	if e.Frames > 0 {
+		return e.Frames
+	}
+	columns, rows := e.SheetSize()
+	return columns * rows
+}
+
+// SheetSize returns the columns and rows of frames in the texture image, 1x1 if it is not animated
+func (e TextureEntry) SheetSize() (int, int) {
+	columns, rows := e.Columns, e.Rows
+	if columns < 1 {
+		columns = 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	return columns, rows
+}
+
+// LoadTextureManifest reads and validates the texture manifest at the given path of the file system,
+// with the file of each texture resolved to its path in the file system
+func LoadTextureManifest(fsys fs.FS, filePath string) (*TextureManifest, error) {
+	data, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read texture manifest: %w", err)
+	}
+
+	tm, err := ParseTextureManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid texture manifest %s: %w", filePath, err)
+	}
+
+	for i := range tm.Textures {
+		tm.Textures[i].File = path.Join(path.Dir(filePath), tm.Textures[i].File)
+		if tm.Textures[i].Data != "" {
+			tm.Textures[i].Data = path.Join(path.Dir(filePath), tm.Textures[i].Data)
+		}
+	}
+	return tm, nil
+}
+
+// ParseTextureManifest creates a texture manifest from the contents of a JSON texture manifest file
+func ParseTextureManifest(data []byte) (*TextureManifest, error) {
+	var tm TextureManifest
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&tm); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]struct{}, len(tm.Textures))
+	wallIDs := make(map[int]string)
+	for _, entry := range tm.Textures {
+		if entry.Name == "" {
+			return nil, fmt.Errorf("texture with file %q has no name", entry.File)
+		}
+		if _, ok := names[entry.Name]; ok {
+			return nil, fmt.Errorf("texture %q declared more than once", entry.Name)
+		}
+		names[entry.Name] = struct{}{}
+
+		if entry.File == "" {
+			return nil, fmt.Errorf("texture %q has no file", entry.Name)
+		}
+		if entry.Data != "" && entry.Kind != TextureSheet {
+			return nil, fmt.Errorf("%s texture %q cannot have sprite sheet data", entry.Kind, entry.Name)
+		}
+
+		switch entry.Kind {
+		case TextureWall:
+			if entry.ID <= 0 {
+				return nil, fmt.Errorf("wall texture %q needs an id greater than 0", entry.Name)
+			}
+			if other, ok := wallIDs[entry.ID]; ok {
+				return nil, fmt.Errorf("wall texture %q has the same id %d as %q", entry.Name, entry.ID, other)
+			}
+			wallIDs[entry.ID] = entry.Name
+			if err := validateAnimation(entry); err != nil {
+				return nil, err
+			}
+		case TextureFloor, TextureSky, TextureSprite, TextureSheet:
+			if entry.ID != 0 {
+				return nil, fmt.Errorf("%s texture %q cannot have an id", entry.Kind, entry.Name)
+			}
+			if entry.Columns != 0 || entry.Rows != 0 || entry.Frames != 0 || entry.Rate != 0 {
+				return nil, fmt.Errorf("%s texture %q cannot be animated", entry.Kind, entry.Name)
+			}
+		default:
+			return nil, fmt.Errorf("texture %q has unknown kind %q", entry.Name, entry.Kind)
+		}
+	}
+
+	return &tm, nil
+}
+
+// validateAnimation makes sure the frames of an animated wall texture fit its sprite sheet and have a frame rate
+func validateAnimation(entry TextureEntry) error {
+	if entry.Columns < 0 || entry.Rows < 0 || entry.Frames < 0 {
+		return fmt.Errorf("wall texture %q has negative columns, rows or frames", entry.Name)
+	}
+	columns, rows := entry.SheetSize()
+	if entry.Frames > columns*rows {
+		return fmt.Errorf("wall texture %q has %d frames, more than its %dx%d sheet", entry.Name, entry.Frames, columns, rows)
+	}
+	if entry.IsAnimated() && entry.Rate <= 0 {
+		return fmt.Errorf("animated wall texture %q needs a rate greater than 0", entry.Name)
+	}
+	if !entry.IsAnimated() && entry.Rate != 0 {
+		return fmt.Errorf("wall texture %q has a rate but only one frame", entry.Name)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadTextureManifest(t *testing.T) {
+	fsys := fstest.MapFS{
+		"resources/textures.json": {Data: []byte(`{
+			"textures": [
+				{"name": "stone", "file": "textures/stone.png", "kind": "wall", "id": 1},
+				{"name": "sky", "file": "textures/sky.png", "kind": "sky"},
+				{"name": "slime_sheet", "file": "sprites/slime.png", "kind": "sheet", "data": "sprites/slime.json"}
+			]
+		}`)},
+	}
+
+	tm, err := LoadTextureManifest(fsys, "resources/textures.json")
+	if err != nil {
+		t.Fatalf("LoadTextureManifest() error = %v", err)
+	}
+	if len(tm.Textures) != 3 {
+		t.Fatalf("len(Textures) = %d, want 3", len(tm.Textures))
+	}
+
+	stone := tm.Textures[0]
+	if stone.Name != "stone" || stone.Kind != TextureWall || stone.ID != 1 || stone.File != "resources/textures/stone.png" {
+		t.Errorf("Textures[0] = %+v, want wall texture 1 at resources/textures/stone.png", stone)
+	}
+	if stone.IsAnimated() || stone.NumFrames() != 1 {
+		t.Errorf("stone IsAnimated() = %v with %d frames, want a single frame", stone.IsAnimated(), stone.NumFrames())
+	}
+	if sheet := tm.Textures[2]; sheet.Data != "resources/sprites/slime.json" {
+		t.Errorf("Textures[2].Data = %q, want resources/sprites/slime.json", sheet.Data)
+	}
+
+	if _, err := LoadTextureManifest(fsys, "missing.json"); err == nil {
+		t.Error("LoadTextureManifest() of a missing file succeeded, want error")
+	}
+}
+
+func TestParseTextureManifestErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"unknown field", `{"textures": [{"name": "stone", "file": "stone.png", "kind": "wall", "id": 1, "size": 64}]}`, `unknown field "size"`},
+		{"no name", `{"textures": [{"file": "stone.png", "kind": "wall", "id": 1}]}`, `texture with file "stone.png" has no name`},
+		{
+			"declared twice",
+			`{"textures": [{"name": "sky", "file": "sky.png", "kind": "sky"}, {"name": "sky", "file": "night.png", "kind": "sky"}]}`,
+			`texture "sky" declared more than once`,
+		},
+		{"no file", `{"textures": [{"name": "sky", "kind": "sky"}]}`, `texture "sky" has no file`},
+		{"unknown kind", `{"textures": [{"name": "sky", "file": "sky.png", "kind": "ceiling"}]}`, `texture "sky" has unknown kind "ceiling"`},
+		{"no kind", `{"textures": [{"name": "sky", "file": "sky.png"}]}`, `texture "sky" has unknown kind ""`},
+		{"wall without id", `{"textures": [{"name": "stone", "file": "stone.png", "kind": "wall"}]}`, `wall texture "stone" needs an id greater than 0`},
+		{
+			"wall id twice",
+			`{"textures": [{"name": "stone", "file": "stone.png", "kind": "wall", "id": 1}, {"name": "wood", "file": "wood.png", "kind": "wall", "id": 1}]}`,
+			`wall texture "wood" has the same id 1 as "stone"`,
+		},
+		{"id of sprite", `{"textures": [{"name": "rock", "file": "rock.png", "kind": "sprite", "id": 2}]}`, `sprite texture "rock" cannot have an id`},
+		{"animated floor", `{"textures": [{"name": "grass", "file": "grass.png", "kind": "floor", "columns": 2}]}`, `floor texture "grass" cannot be animated`},
+		{
+			"animated wall without rate",
+			`{"textures": [{"name": "lava", "file": "lava.png", "kind": "wall", "id": 2, "columns": 4, "rows": 2}]}`,
+			`animated wall texture "lava" needs a rate greater than 0`,
+		},
+		{"sheet data of sprite", `{"textures": [{"name": "rock", "file": "rock.png", "kind": "sprite", "data": "rock.json"}]}`, `sprite texture "rock" cannot have sprite sheet data`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTextureManifest([]byte(tt.json))
+			if err == nil {
+				t.Fatalf("ParseTextureManifest() succeeded, want error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseTextureManifest() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAnimation(t *testing.T) {
+	tests := []struct {
+		name       string
+		entry      TextureEntry
+		wantFrames int
+		want       string
+	}{
+		{"single frame", TextureEntry{Name: "stone"}, 1, ""},
+		{"full sheet", TextureEntry{Name: "lava", Columns: 4, Rows: 2, Rate: 8}, 8, ""},
+		{"partial last row", TextureEntry{Name: "lava", Columns: 4, Rows: 2, Frames: 6, Rate: 8}, 6, ""},
+		{"single column", TextureEntry{Name: "lava", Rows: 3, Rate: 8}, 3, ""},
+		{"one frame sheet", TextureEntry{Name: "lava", Columns: 4, Rows: 2, Frames: 1}, 1, ""},
+		{"negative frames", TextureEntry{Name: "lava", Columns: 4, Frames: -1, Rate: 8}, 4, `wall texture "lava" has negative columns, rows or frames`},
+		{"too many frames", TextureEntry{Name: "lava", Columns: 4, Rows: 2, Frames: 9, Rate: 8}, 9, `wall texture "lava" has 9 frames, more than its 4x2 sheet`},
+		{"no rate", TextureEntry{Name: "lava", Columns: 4}, 4, `animated wall texture "lava" needs a rate greater than 0`},
+		{"negative rate", TextureEntry{Name: "lava", Columns: 4, Rate: -1}, 4, `animated wall texture "lava" needs a rate greater than 0`},
+		{"rate of one frame", TextureEntry{Name: "stone", Rate: 8}, 1, `wall texture "stone" has a rate but only one frame`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.entry.NumFrames(); got != tt.wantFrames {
+				t.Errorf("NumFrames() = %d, want %d", got, tt.wantFrames)
+			}
+
+			err := validateAnimation(tt.entry)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("validateAnimation() error = %q, want none", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("validateAnimation() succeeded, want error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("validateAnimation() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,911 @@
+package model
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/harbdog/raycaster-go/geom"
+)
+
+// Tiled map import, supporting the TMX (https://doc.mapeditor.org/en/stable/reference/tmx-map-format/)
+// and JSON (https://doc.mapeditor.org/en/stable/reference/json-map-format/) map formats:
+//   - tile layers become the wall levels, in order from the ground up
+//   - a tile layer named "floor" becomes the floor texture layer instead
+//   - a tile layer named "hazards" becomes the hazards layer, using the tile IDs as the hazards
+//   - objects of type "spawn" become the player spawn
+//   - objects of type "faces" set the wall textures per face of the wall cell they are placed on
+//   - objects of type "door" make the wall cell they are placed on a door
+//   - objects of type "destructible" make the wall cell they are placed on destructible
+//   - objects of type "trigger" become trigger areas covering the area of the object
+//   - all other objects become sprite placements, using the object type (or name) as the sprite type
+//   - tileset tiles used by the tile layers become the map wall textures
+//   - the "environment" map property sets the map environment as a JSON object (see "environment" in README.md)
+
+const (
+	// Tiled stores tile flipping in the highest bits of the global tile IDs
+	tiledFlipFlags = 0xF0000000
+
+	tiledSpawnType   = "spawn"
+	tiledDoorType    = "door"
+	tiledFacesType   = "faces"
+	tiledTriggerType = "trigger"
+
+	tiledDestructibleType = "destructible"
+
+	tiledFloorLayer   = "floor"
+	tiledHazardsLayer = "hazards"
+)
+
+// tiledMap is the format independent representation of a Tiled map
+type tiledMap struct {
+	width, height         int
+	tileWidth, tileHeight int
+	infinite              bool
+	tilesets              []*tiledTileset
+	layers                []*tiledLayer
+	properties            map[string]string
+}
+
+type tiledTileset struct {
+	firstGID                int
+	tileWidth, tileHeight   int
+	tileCount, columns      int
+	margin, spacing         int
+	image                   string
+	imageWidth, imageHeight int
+	tiles                   map[int]string
+}
+
+type tiledLayer struct {
+	name    string
+	isTiles bool
+	data    []uint32
+	objects []*tiledObject
+}
+
+type tiledObject struct {
+	name, objType       string
+	x, y, width, height float64
+	isTile              bool
+	properties          map[string]string
+}
+
+// LoadTiledMap imports the Tiled map (.tmx or .tmj) at the given path of the file system
+func LoadTiledMap(fsys fs.FS, filePath string) (*Map, error) {
+	data, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var tm *tiledMap
+	if strings.ToLower(path.Ext(filePath)) == ".tmx" {
+		tm, err = parseTMX(fsys, filePath, data)
+	} else {
+		tm, err = parseTMJ(fsys, filePath, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return tm.toMap()
+}
+
+func (tm *tiledMap) toMap() (*Map, error) {
+	if tm.infinite {
+		return nil, fmt.Errorf("infinite maps are not supported")
+	}
+	if tm.tileWidth <= 0 || tm.tileHeight <= 0 {
+		return nil, fmt.Errorf("invalid tile size %dx%d", tm.tileWidth, tm.tileHeight)
+	}
+
+	m := &Map{}
+	usedIDs := make(map[int]struct{})
+
+	for _, layer := range tm.layers {
+		if !layer.isTiles {
+			continue
+		}
+		if len(layer.data) != tm.width*tm.height {
+			return nil, fmt.Errorf("tile layer %q has %d tiles, expected %d", layer.name, len(layer.data), tm.width*tm.height)
+		}
+
+		// Tiled layer data is stored row by row, levels are indexed by [x][y]
+		isHazards := strings.ToLower(layer.name) == tiledHazardsLayer
+		level := make([][]int, tm.width)
+		for x := range level {
+			level[x] = make([]int, tm.height)
+			for y := range level[x] {
+				id := int(layer.data[y*tm.width+x] &^ tiledFlipFlags)
+				level[x][y] = id
+				if id > 0 && !isHazards {
+					usedIDs[id] = struct{}{}
+				}
+			}
+		}
+
+		switch strings.ToLower(layer.name) {
+		case tiledHazardsLayer:
+			m.hazards = level
+		case tiledFloorLayer:
+			m.floor = level
+		default:
+			m.levels = append(m.levels, level)
+		}
+	}
+
+	if err := validateLevels(m.levels); err != nil {
+		return nil, err
+	}
+	if err := validateHazards(m.hazards, m.levels[0]); err != nil {
+		return nil, err
+	}
+
+	for _, layer := range tm.layers {
+		for _, obj := range layer.objects {
+			if err := tm.addObject(m, obj); err != nil {
+				return nil, fmt.Errorf("object layer %q: %w", layer.name, err)
+			}
+		}
+	}
+
+	if environment := tm.properties["environment"]; environment != "" {
+		var fe mapFileEnvironment
+		if err := json.Unmarshal([]byte(environment), &fe); err != nil {
+			return nil, fmt.Errorf("map property %q: %w", "environment", err)
+		}
+		m.environment = newMapFileEnvironment(fe)
+		if err := m.environment.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := m.initDoors(); err != nil {
+		return nil, err
+	}
+	if err := m.initDestructibleWalls(); err != nil {
+		return nil, err
+	}
+	if err := m.validatePlacements(); err != nil {
+		return nil, err
+	}
+
+	for _, faces := range m.faces {
+		for _, id := range faces {
+			if id > 0 {
+				usedIDs[id] = struct{}{}
+			}
+		}
+	}
+	for _, w := range m.destructibles {
+		for _, id := range w.Stages {
+			if id > 0 {
+				usedIDs[id] = struct{}{}
+			}
+		}
+	}
+
+	tiles, err := tm.mapTiles(usedIDs)
+	if err != nil {
+		return nil, err
+	}
+	m.tiles = tiles
+
+	return m, nil
+}
+
+func (tm *tiledMap) addObject(m *Map, obj *tiledObject) error {
+	// use the center of the object as its map position, noting that tile objects are aligned bottom-left
+	pX, pY := obj.x+obj.width/2, obj.y+obj.height/2
+	if obj.isTile {
+		pY = obj.y - obj.height/2
+	}
+	x, y := pX/float64(tm.tileWidth), pY/float64(tm.tileHeight)
+
+	angle, err := obj.floatProperty("angle")
+	if err != nil {
+		return err
+	}
+
+	objType := obj.objType
+	if objType == "" {
+		objType = obj.name
+	}
+
+	if objType == tiledSpawnType {
+		m.spawn = &MapSpawn{X: x, Y: y, Angle: geom.Radians(angle)}
+		return nil
+	}
+	if objType == tiledDoorType {
+		return addTiledDoor(m, obj, x, y)
+	}
+	if objType == tiledFacesType {
+		return addTiledFaces(m, obj, x, y)
+	}
+	if objType == tiledTriggerType {
+		return tm.addTiledTrigger(m, obj)
+	}
+	if objType == tiledDestructibleType {
+		return addTiledDestructible(m, obj, x, y)
+	}
+	if objType == "" {
+		return fmt.Errorf("object at (%v, %v) has no type or name", obj.x, obj.y)
+	}
+
+	z, err := obj.floatProperty("z")
+	if err != nil {
+		return err
+	}
+	velocity, err := obj.floatProperty("velocity")
+	if err != nil {
+		return err
+	}
+	scale, err := obj.floatProperty("scale")
+	if err != nil {
+		return err
+	}
+
+	m.sprites = append(m.sprites, MapSprite{
+		Type: objType, X: x, Y: y, Z: z, Angle: geom.Radians(angle), Velocity: velocity, Scale: scale,
+	})
+	return nil
+}
+
+func addTiledDoor(m *Map, obj *tiledObject, x, y float64) error {
+	door := NewDoor(int(x), int(y), 0)
+
+	var err error
+	if door.Locked, err = obj.boolProperty("locked"); err != nil {
+		return err
+	}
+	if door.Auto, err = obj.boolProperty("auto"); err != nil {
+		return err
+	}
+	if _, ok := obj.properties["closeDelay"]; ok {
+		if door.CloseDelay, err = obj.floatProperty("closeDelay"); err != nil {
+			return err
+		}
+	}
+
+	m.doors = append(m.doors, door)
+	return nil
+}
+
+func addTiledFaces(m *Map, obj *tiledObject, x, y float64) error {
+	var values [5]float64
+	for i, name := range []string{"level", "north", "south", "east", "west"} {
+		var err error
+		if values[i], err = obj.floatProperty(name); err != nil {
+			return err
+		}
+	}
+
+	cell := MapCell{Level: int(values[0]), X: int(x), Y: int(y)}
+	return m.addFaces(cell, MapFaces{
+		FaceNorth: int(values[1]), FaceSouth: int(values[2]), FaceEast: int(values[3]), FaceWest: int(values[4]),
+	})
+}
+
+// addTiledDestructible makes the wall cell the object is placed on destructible, with the optional "level"
+// property and the "health" and "stages" (comma separated tile IDs) properties
+func addTiledDestructible(m *Map, obj *tiledObject, x, y float64) error {
+	level, err := obj.floatProperty("level")
+	if err != nil {
+		return err
+	}
+	health, err := obj.floatProperty("health")
+	if err != nil {
+		return err
+	}
+
+	var stages []int
+	if value := obj.properties["stages"]; value != "" {
+		for _, s := range strings.Split(value, ",") {
+			stage, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return fmt.Errorf("object %q property %q: %w", obj.name, "stages", err)
+			}
+			stages = append(stages, stage)
+		}
+	}
+
+	cell := MapCell{Level: int(level), X: int(x), Y: int(y)}
+	m.destructibles = append(m.destructibles, NewDestructibleWall(cell, health, stages))
+	return nil
+}
+
+// addTiledTrigger adds a trigger covering the area of the object, with the comma separated trigger entities
+// in the "entities" property and the actions as a JSON array in the "actions" property (see "triggers" in README.md)
+func (tm *tiledMap) addTiledTrigger(m *Map, obj *tiledObject) error {
+	ft := mapFileTrigger{
+		X: obj.x / float64(tm.tileWidth), Y: obj.y / float64(tm.tileHeight),
+		Event: TriggerEvent(obj.properties["event"]),
+	}
+	// objects without a size (such as points) trigger the cell they are placed on
+	if obj.width > 0 && obj.height > 0 {
+		width, height := obj.width/float64(tm.tileWidth), obj.height/float64(tm.tileHeight)
+		ft.Width, ft.Height = &width, &height
+	} else {
+		ft.X, ft.Y = math.Floor(ft.X), math.Floor(ft.Y)
+	}
+
+	if entities := obj.properties["entities"]; entities != "" {
+		for _, e := range strings.Split(entities, ",") {
+			ft.Entities = append(ft.Entities, TriggerEntity(strings.TrimSpace(e)))
+		}
+	}
+
+	var err error
+	if ft.Once, err = obj.boolProperty("once"); err != nil {
+		return err
+	}
+	if _, ok := obj.properties["interval"]; ok {
+		interval, err := obj.floatProperty("interval")
+		if err != nil {
+			return err
+		}
+		ft.Interval = &interval
+	}
+	if actions := obj.properties["actions"]; actions != "" {
+		if err := json.Unmarshal([]byte(actions), &ft.Actions); err != nil {
+			return fmt.Errorf("object %q property %q: %w", obj.name, "actions", err)
+		}
+	}
+
+	m.triggers = append(m.triggers, newMapFileTrigger(ft))
+	return nil
+}
+
+func newTiledObject(name, objType, class string, x, y, width, height float64, gid uint32) *tiledObject {
+	if objType == "" {
+		// newer versions of Tiled call the object type its class
+		objType = class
+	}
+	return &tiledObject{
+		name: name, objType: objType,
+		x: x, y: y, width: width, height: height,
+		isTile:     gid != 0,
+		properties: make(map[string]string),
+	}
+}
+
+func (obj *tiledObject) floatProperty(name string) (float64, error) {
+	value, ok := obj.properties[name]
+	if !ok || value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("object %q property %q: %w", obj.name, name, err)
+	}
+	return f, nil
+}
+
+func (obj *tiledObject) boolProperty(name string) (bool, error) {
+	value, ok := obj.properties[name]
+	if !ok || value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("object %q property %q: %w", obj.name, name, err)
+	}
+	return b, nil
+}
+
+// mapTiles determines the image and area within it for each of the given tile IDs
+func (tm *tiledMap) mapTiles(ids map[int]struct{}) ([]MapTile, error) {
+	// tilesets are searched from the highest first ID down to find the tileset of a tile ID
+	tilesets := make([]*tiledTileset, len(tm.tilesets))
+	copy(tilesets, tm.tilesets)
+	sort.Slice(tilesets, func(i, j int) bool {
+		return tilesets[i].firstGID > tilesets[j].firstGID
+	})
+
+	tiles := make([]MapTile, 0, len(ids))
+	for id := range ids {
+		var ts *tiledTileset
+		for _, t := range tilesets {
+			if id >= t.firstGID {
+				ts = t
+				break
+			}
+		}
+		if ts == nil {
+			return nil, fmt.Errorf("tile %d does not belong to any tileset", id)
+		}
+
+		tile, err := ts.mapTile(id)
+		if err != nil {
+			return nil, err
+		}
+		tiles = append(tiles, tile)
+	}
+
+	sort.Slice(tiles, func(i, j int) bool {
+		return tiles[i].ID < tiles[j].ID
+	})
+	return tiles, nil
+}
+
+func (ts *tiledTileset) mapTile(id int) (MapTile, error) {
+	localID := id - ts.firstGID
+
+	if tileImage, ok := ts.tiles[localID]; ok {
+		// image collection tileset, the whole image is used
+		return MapTile{ID: id, Image: tileImage}, nil
+	}
+	if ts.image == "" {
+		return MapTile{}, fmt.Errorf("tile %d has no image", id)
+	}
+
+	columns := ts.columns
+	if columns <= 0 && ts.tileWidth > 0 {
+		columns = (ts.imageWidth - 2*ts.margin + ts.spacing) / (ts.tileWidth + ts.spacing)
+	}
+	if columns <= 0 || (ts.tileCount > 0 && localID >= ts.tileCount) {
+		return MapTile{}, fmt.Errorf("tile %d is outside of tileset image %s", id, ts.image)
+	}
+
+	col, row := localID%columns, localID/columns
+	x := ts.margin + col*(ts.tileWidth+ts.spacing)
+	y := ts.margin + row*(ts.tileHeight+ts.spacing)
+
+	return MapTile{ID: id, Image: ts.image, Rect: image.Rect(x, y, x+ts.tileWidth, y+ts.tileHeight)}, nil
+}
+
+// decodeTiledData decodes base64 encoded (and optionally compressed) tile layer data
+func decodeTiledData(encoded, compression string) ([]uint32, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader = bytes.NewReader(raw)
+	switch compression {
+	case "":
+	case "zlib":
+		r, err = zlib.NewReader(r)
+	case "gzip":
+		r, err = gzip.NewReader(r)
+	default:
+		return nil, fmt.Errorf("unsupported tile layer compression %q", compression)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err = io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw)%4 != 0 {
+		return nil, fmt.Errorf("invalid tile layer data length %d", len(raw))
+	}
+
+	data := make([]uint32, len(raw)/4)
+	for i := range data {
+		data[i] = binary.LittleEndian.Uint32(raw[i*4:])
+	}
+	return data, nil
+}
+
+// decodeTiledCSV decodes CSV encoded tile layer data
+func decodeTiledCSV(encoded string) ([]uint32, error) {
+	fields := strings.FieldsFunc(encoded, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\r' || r == '\t'
+	})
+
+	data := make([]uint32, len(fields))
+	for i, field := range fields {
+		id, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		data[i] = uint32(id)
+	}
+	return data, nil
+}
+
+// resolveTiledPath resolves a file referenced by a Tiled file relative to the referencing file
+func resolveTiledPath(fromFile, ref string) string {
+	return path.Join(path.Dir(fromFile), ref)
+}
+
+//--TMX (XML) format--//
+
+type tmxMap struct {
+	Width      int           `xml:"width,attr"`
+	Height     int           `xml:"height,attr"`
+	TileWidth  int           `xml:"tilewidth,attr"`
+	TileHeight int           `xml:"tileheight,attr"`
+	Infinite   int           `xml:"infinite,attr"`
+	Tilesets   []tmxTileset  `xml:"tileset"`
+	Properties []tmxProperty `xml:"properties>property"`
+	Layers     []tmxLayer    `xml:",any"`
+}
+
+type tmxTileset struct {
+	FirstGID   int       `xml:"firstgid,attr"`
+	Source     string    `xml:"source,attr"`
+	TileWidth  int       `xml:"tilewidth,attr"`
+	TileHeight int       `xml:"tileheight,attr"`
+	TileCount  int       `xml:"tilecount,attr"`
+	Columns    int       `xml:"columns,attr"`
+	Margin     int       `xml:"margin,attr"`
+	Spacing    int       `xml:"spacing,attr"`
+	Image      tmxImage  `xml:"image"`
+	Tiles      []tmxTile `xml:"tile"`
+}
+
+type tmxImage struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+}
+
+type tmxTile struct {
+	ID    int      `xml:"id,attr"`
+	Image tmxImage `xml:"image"`
+}
+
+// tmxLayer is any of the layer, objectgroup or group elements
+type tmxLayer struct {
+	XMLName xml.Name
+	Name    string      `xml:"name,attr"`
+	Data    tmxData     `xml:"data"`
+	Objects []tmxObject `xml:"object"`
+	Layers  []tmxLayer  `xml:",any"`
+}
+
+type tmxData struct {
+	Encoding    string `xml:"encoding,attr"`
+	Compression string `xml:"compression,attr"`
+	Text        string `xml:",chardata"`
+	Tiles       []struct {
+		GID uint32 `xml:"gid,attr"`
+	} `xml:"tile"`
+}
+
+type tmxObject struct {
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	X          float64       `xml:"x,attr"`
+	Y          float64       `xml:"y,attr"`
+	Width      float64       `xml:"width,attr"`
+	Height     float64       `xml:"height,attr"`
+	GID        uint32        `xml:"gid,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+}
+
+type tmxProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+	Text  string `xml:",chardata"`
+}
+
+// value returns the value attribute of the property, or its text for multiline string properties
+func (p tmxProperty) value() string {
+	if p.Value == "" {
+		return strings.TrimSpace(p.Text)
+	}
+	return p.Value
+}
+
+func parseTMX(fsys fs.FS, filePath string, data []byte) (*tiledMap, error) {
+	var tmx tmxMap
+	if err := xml.Unmarshal(data, &tmx); err != nil {
+		return nil, err
+	}
+
+	tm := &tiledMap{
+		width: tmx.Width, height: tmx.Height,
+		tileWidth: tmx.TileWidth, tileHeight: tmx.TileHeight,
+		infinite:   tmx.Infinite != 0,
+		properties: make(map[string]string, len(tmx.Properties)),
+	}
+	for _, p := range tmx.Properties {
+		tm.properties[p.Name] = p.value()
+	}
+
+	for _, tsx := range tmx.Tilesets {
+		if tsx.Source == "" {
+			tm.tilesets = append(tm.tilesets, tsxTileset(filePath, tsx))
+			continue
+		}
+
+		ts, err := loadTiledTileset(fsys, resolveTiledPath(filePath, tsx.Source))
+		if err != nil {
+			return nil, err
+		}
+		ts.firstGID = tsx.FirstGID
+		tm.tilesets = append(tm.tilesets, ts)
+	}
+
+	layers, err := flattenTMXLayers(tmx.Layers)
+	if err != nil {
+		return nil, err
+	}
+	tm.layers = layers
+
+	return tm, nil
+}
+
+// flattenTMXLayers flattens layers and groups of layers into a single list of tile and object layers
+func flattenTMXLayers(tmxLayers []tmxLayer) ([]*tiledLayer, error) {
+	layers := []*tiledLayer{}
+	for _, l := range tmxLayers {
+		switch l.XMLName.Local {
+		case "layer":
+			layer := &tiledLayer{name: l.Name, isTiles: true}
+			var err error
+			switch l.Data.Encoding {
+			case "csv":
+				layer.data, err = decodeTiledCSV(l.Data.Text)
+			case "base64":
+				layer.data, err = decodeTiledData(l.Data.Text, l.Data.Compression)
+			case "":
+				layer.data = make([]uint32, len(l.Data.Tiles))
+				for i, t := range l.Data.Tiles {
+					layer.data[i] = t.GID
+				}
+			default:
+				err = fmt.Errorf("unsupported encoding %q", l.Data.Encoding)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("tile layer %q: %w", l.Name, err)
+			}
+			layers = append(layers, layer)
+
+		case "objectgroup":
+			layer := &tiledLayer{name: l.Name}
+			for _, o := range l.Objects {
+				obj := newTiledObject(o.Name, o.Type, o.Class, o.X, o.Y, o.Width, o.Height, o.GID)
+				for _, p := range o.Properties {
+					obj.properties[p.Name] = p.value()
+				}
+				layer.objects = append(layer.objects, obj)
+			}
+			layers = append(layers, layer)
+
+		case "group":
+			groupLayers, err := flattenTMXLayers(l.Layers)
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, groupLayers...)
+		}
+	}
+	return layers, nil
+}
+
+//--TMJ (JSON) format--//
+
+type tmjMap struct {
+	Width      int           `json:"width"`
+	Height     int           `json:"height"`
+	TileWidth  int           `json:"tilewidth"`
+	TileHeight int           `json:"tileheight"`
+	Infinite   bool          `json:"infinite"`
+	Tilesets   []tmjTileset  `json:"tilesets"`
+	Properties []tmjProperty `json:"properties"`
+	Layers     []tmjLayer    `json:"layers"`
+}
+
+type tmjTileset struct {
+	FirstGID    int       `json:"firstgid"`
+	Source      string    `json:"source"`
+	TileWidth   int       `json:"tilewidth"`
+	TileHeight  int       `json:"tileheight"`
+	TileCount   int       `json:"tilecount"`
+	Columns     int       `json:"columns"`
+	Margin      int       `json:"margin"`
+	Spacing     int       `json:"spacing"`
+	Image       string    `json:"image"`
+	ImageWidth  int       `json:"imagewidth"`
+	ImageHeight int       `json:"imageheight"`
+	Tiles       []tmjTile `json:"tiles"`
+}
+
+type tmjTile struct {
+	ID    int    `json:"id"`
+	Image string `json:"image"`
+}
+
+type tmjLayer struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name"`
+	Data        json.RawMessage `json:"data"`
+	Encoding    string          `json:"encoding"`
+	Compression string          `json:"compression"`
+	Objects     []tmjObject     `json:"objects"`
+	Layers      []tmjLayer      `json:"layers"`
+}
+
+type tmjObject struct {
+	Name       string        `json:"name"`
+	Type       string        `json:"type"`
+	Class      string        `json:"class"`
+	X          float64       `json:"x"`
+	Y          float64       `json:"y"`
+	Width      float64       `json:"width"`
+	Height     float64       `json:"height"`
+	GID        uint32        `json:"gid"`
+	Properties []tmjProperty `json:"properties"`
+}
+
+type tmjProperty struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+func parseTMJ(fsys fs.FS, filePath string, data []byte) (*tiledMap, error) {
+	var tmj tmjMap
+	if err := json.Unmarshal(data, &tmj); err != nil {
+		return nil, err
+	}
+
+	tm := &tiledMap{
+		width: tmj.Width, height: tmj.Height,
+		tileWidth: tmj.TileWidth, tileHeight: tmj.TileHeight,
+		infinite:   tmj.Infinite,
+		properties: make(map[string]string, len(tmj.Properties)),
+	}
+	for _, p := range tmj.Properties {
+		tm.properties[p.Name] = tmjPropertyString(p.Value)
+	}
+
+	for _, tsj := range tmj.Tilesets {
+		if tsj.Source == "" {
+			tm.tilesets = append(tm.tilesets, tsjTileset(filePath, tsj))
+			continue
+		}
+
+		ts, err := loadTiledTileset(fsys, resolveTiledPath(filePath, tsj.Source))
+		if err != nil {
+			return nil, err
+		}
+		ts.firstGID = tsj.FirstGID
+		tm.tilesets = append(tm.tilesets, ts)
+	}
+
+	layers, err := flattenTMJLayers(tmj.Layers)
+	if err != nil {
+		return nil, err
+	}
+	tm.layers = layers
+
+	return tm, nil
+}
+
+// loadTiledTileset loads an external tileset file (.tsx or .tsj)
+func loadTiledTileset(fsys fs.FS, tsPath string) (*tiledTileset, error) {
+	data, err := fs.ReadFile(fsys, tsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.ToLower(path.Ext(tsPath)) == ".tsx" {
+		var tsx tmxTileset
+		if err := xml.Unmarshal(data, &tsx); err != nil {
+			return nil, fmt.Errorf("%s: %w", tsPath, err)
+		}
+		return tsxTileset(tsPath, tsx), nil
+	}
+
+	var tsj tmjTileset
+	if err := json.Unmarshal(data, &tsj); err != nil {
+		return nil, fmt.Errorf("%s: %w", tsPath, err)
+	}
+	return tsjTileset(tsPath, tsj), nil
+}
+
+func tsjTileset(tsjPath string, tsj tmjTileset) *tiledTileset {
+	ts := &tiledTileset{
+		firstGID:  tsj.FirstGID,
+		tileWidth: tsj.TileWidth, tileHeight: tsj.TileHeight,
+		tileCount: tsj.TileCount, columns: tsj.Columns,
+		margin: tsj.Margin, spacing: tsj.Spacing,
+		imageWidth: tsj.ImageWidth, imageHeight: tsj.ImageHeight,
+		tiles: make(map[int]string),
+	}
+	if tsj.Image != "" {
+		ts.image = resolveTiledPath(tsjPath, tsj.Image)
+	}
+	for _, tile := range tsj.Tiles {
+		if tile.Image != "" {
+			ts.tiles[tile.ID] = resolveTiledPath(tsjPath, tile.Image)
+		}
+	}
+	return ts
+}
+
+func tsxTileset(tsxPath string, tsx tmxTileset) *tiledTileset {
+	ts := &tiledTileset{
+		firstGID:  tsx.FirstGID,
+		tileWidth: tsx.TileWidth, tileHeight: tsx.TileHeight,
+		tileCount: tsx.TileCount, columns: tsx.Columns,
+		margin: tsx.Margin, spacing: tsx.Spacing,
+		imageWidth: tsx.Image.Width, imageHeight: tsx.Image.Height,
+		tiles: make(map[int]string),
+	}
+	if tsx.Image.Source != "" {
+		ts.image = resolveTiledPath(tsxPath, tsx.Image.Source)
+	}
+	for _, tile := range tsx.Tiles {
+		if tile.Image.Source != "" {
+			ts.tiles[tile.ID] = resolveTiledPath(tsxPath, tile.Image.Source)
+		}
+	}
+	return ts
+}
+
+// flattenTMJLayers flattens layers and groups of layers into a single list of tile and object layers
+func flattenTMJLayers(tmjLayers []tmjLayer) ([]*tiledLayer, error) {
+	layers := []*tiledLayer{}
+	for _, l := range tmjLayers {
+		switch l.Type {
+		case "tilelayer":
+			layer := &tiledLayer{name: l.Name, isTiles: true}
+			var err error
+			if l.Encoding == "base64" {
+				var encoded string
+				if err = json.Unmarshal(l.Data, &encoded); err == nil {
+					layer.data, err = decodeTiledData(encoded, l.Compression)
+				}
+			} else {
+				err = json.Unmarshal(l.Data, &layer.data)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("tile layer %q: %w", l.Name, err)
+			}
+			layers = append(layers, layer)
+
+		case "objectgroup":
+			layer := &tiledLayer{name: l.Name}
+			for _, o := range l.Objects {
+				obj := newTiledObject(o.Name, o.Type, o.Class, o.X, o.Y, o.Width, o.Height, o.GID)
+				for _, p := range o.Properties {
+					obj.properties[p.Name] = tmjPropertyString(p.Value)
+				}
+				layer.objects = append(layer.objects, obj)
+			}
+			layers = append(layers, layer)
+
+		case "group":
+			groupLayers, err := flattenTMJLayers(l.Layers)
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, groupLayers...)
+		}
+	}
+	return layers, nil
+}
+
+func tmjPropertyString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,230 @@
+package model
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/harbdog/raycaster-go/geom"
+)
+
+const testTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" width="3" height="3" tilewidth="32" tileheight="32" infinite="0">
+ <properties>
+  <property name="environment" value="{&quot;sky&quot;: &quot;night&quot;}"/>
+ </properties>
+ <tileset firstgid="1" source="tilesets/walls.tsx"/>
+ <group name="walls">
+  <layer name="ground" width="3" height="3">
+   <data encoding="csv">
+1,1,1,
+2,0,1,
+1,1,1
+</data>
+  </layer>
+ </group>
+ <layer name="Floor" width="3" height="3">
+  <data>
+   <tile/><tile/><tile/>
+   <tile/><tile gid="3"/><tile/>
+   <tile/><tile/><tile/>
+  </data>
+ </layer>
+ <objectgroup name="objects">
+  <object id="1" name="start" type="spawn" x="48" y="48">
+   <properties><property name="angle" type="float" value="90"/></properties>
+  </object>
+  <object id="2" name="bat" class="bat" gid="1" x="48" y="64" width="32" height="32">
+   <properties><property name="z" type="float" value="0.5"/></properties>
+  </object>
+  <object id="3" type="door" x="0" y="32" width="32" height="32">
+   <properties><property name="locked" type="bool" value="true"/></properties>
+  </object>
+  <object id="4" type="trigger" x="32" y="32" width="32" height="32">
+   <properties>
+    <property name="actions">[{"type": "end"}]</property>
+   </properties>
+  </object>
+ </objectgroup>
+</map>`
+
+const testTSX = `<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" name="walls" tilewidth="32" tileheight="32" tilecount="4" columns="2">
+ <image source="../../textures/walls.png" width="64" height="64"/>
+</tileset>`
+
+func TestLoadTiledMapTMX(t *testing.T) {
+	fsys := fstest.MapFS{
+		"levels/test.tmx":           {Data: []byte(testTMX)},
+		"levels/tilesets/walls.tsx": {Data: []byte(testTSX)},
+	}
+
+	m, err := LoadTiledMap(fsys, "levels/test.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tiled stores rows of tiles, levels are indexed by [x][y]
+	level := m.Level(0)
+	if m.NumLevels() != 1 || level[0][1] != 2 || level[1][0] != 1 || level[1][1] != 0 {
+		t.Errorf("Level(0) = %v, want the ground layer indexed by [x][y]", level)
+	}
+	if got := m.FloorAt(1, 1); got != 3 {
+		t.Errorf("FloorAt(1, 1) = %d, want 3 from the floor layer", got)
+	}
+
+	if spawn := m.Spawn(); spawn.X != 1.5 || spawn.Y != 1.5 || spawn.Angle != geom.Radians(90) {
+		t.Errorf("Spawn() = %+v, want (1.5, 1.5) facing 90 degrees", spawn)
+	}
+	// tile objects are aligned by their bottom left corner
+	want := MapSprite{Type: "bat", X: 2, Y: 1.5, Z: 0.5}
+	if sprites := m.Sprites(); len(sprites) != 1 || sprites[0] != want {
+		t.Errorf("Sprites() = %+v, want %+v", sprites, want)
+	}
+	if door := m.DoorAt(0, 1); door == nil || !door.Locked || door.TexNum != 2 {
+		t.Errorf("DoorAt(0, 1) = %+v, want a locked door with texture 2", door)
+	}
+	if triggers := m.Triggers(); len(triggers) != 1 || triggers[0].X != 1 || triggers[0].Y != 1 || triggers[0].Width != 1 {
+		t.Errorf("Triggers() = %+v, want a 1x1 trigger at (1, 1)", triggers)
+	}
+	if env := m.Environment(); env.Sky != "night" {
+		t.Errorf("Environment().Sky = %q, want night", env.Sky)
+	}
+
+	wantTiles := []MapTile{
+		{ID: 1, Image: "textures/walls.png", Rect: image.Rect(0, 0, 32, 32)},
+		{ID: 2, Image: "textures/walls.png", Rect: image.Rect(32, 0, 64, 32)},
+		{ID: 3, Image: "textures/walls.png", Rect: image.Rect(0, 32, 32, 64)},
+	}
+	tiles := m.Tiles()
+	if len(tiles) != len(wantTiles) {
+		t.Fatalf("Tiles() = %+v, want %+v", tiles, wantTiles)
+	}
+	for i := range wantTiles {
+		if tiles[i] != wantTiles[i] {
+			t.Errorf("Tiles()[%d] = %+v, want %+v", i, tiles[i], wantTiles[i])
+		}
+	}
+}
+
+func TestLoadTiledMapTMJ(t *testing.T) {
+	ground := encodeTestTiles(t, []uint32{1, 1, 1, 1, 0, 1, 1, 1, 1}, true)
+	upper := encodeTestTiles(t, []uint32{0, 0, 0, 0, 0, 0, 0, 0, 2 | 0x80000000}, false)
+
+	fsys := fstest.MapFS{
+		"levels/test.tmj": {Data: []byte(`{
+			"width": 3, "height": 3, "tilewidth": 16, "tileheight": 16, "infinite": false,
+			"tilesets": [{
+				"firstgid": 1, "tilewidth": 16, "tileheight": 16, "tilecount": 2,
+				"tiles": [{"id": 0, "image": "../textures/stone.png"}, {"id": 1, "image": "wood.png"}]
+			}],
+			"layers": [
+				{"type": "tilelayer", "name": "ground", "encoding": "base64", "compression": "zlib", "data": "` + ground + `"},
+				{"type": "tilelayer", "name": "upper", "encoding": "base64", "data": "` + upper + `"},
+				{"type": "objectgroup", "name": "objects", "objects": [
+					{"name": "spawn", "x": 24, "y": 24, "properties": [{"name": "angle", "type": "float", "value": 180}]},
+					{"type": "walker", "x": 16, "y": 16, "width": 16, "height": 16,
+						"properties": [{"name": "velocity", "type": "float", "value": 0.02}]}
+				]}
+			]
+		}`)},
+	}
+
+	m, err := LoadTiledMap(fsys, "levels/test.tmj")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.NumLevels() != 2 || m.Level(0)[1][0] != 1 || m.Level(0)[1][1] != 0 {
+		t.Errorf("Level(0) = %v, want the decompressed ground layer", m.Level(0))
+	}
+	// the flip flags are not part of the tile ID
+	if got := m.Level(1)[2][2]; got != 2 {
+		t.Errorf("Level(1)[2][2] = %d, want 2 without the flip flag", got)
+	}
+	if spawn := m.Spawn(); spawn.X != 1.5 || spawn.Y != 1.5 || spawn.Angle != geom.Radians(180) {
+		t.Errorf("Spawn() = %+v, want (1.5, 1.5) facing 180 degrees from the object named spawn", spawn)
+	}
+	want := MapSprite{Type: "walker", X: 1.5, Y: 1.5, Velocity: 0.02}
+	if sprites := m.Sprites(); len(sprites) != 1 || sprites[0] != want {
+		t.Errorf("Sprites() = %+v, want %+v", sprites, want)
+	}
+
+	// image collection tiles use their whole image
+	wantTiles := []MapTile{{ID: 1, Image: "textures/stone.png"}, {ID: 2, Image: "levels/wood.png"}}
+	if tiles := m.Tiles(); len(tiles) != 2 || tiles[0] != wantTiles[0] || tiles[1] != wantTiles[1] {
+		t.Errorf("Tiles() = %+v, want %+v", tiles, wantTiles)
+	}
+}
+
+func TestLoadTiledMapErrors(t *testing.T) {
+	const tileset = `"tilesets": [{"firstgid": 1, "tilewidth": 16, "tileheight": 16, "tilecount": 2, "columns": 2, "image": "walls.png"}]`
+	const spawn = `{"type": "objectgroup", "name": "objects", "objects": [{"type": "spawn", "x": 24, "y": 24}]}`
+	const walls = `{"type": "tilelayer", "name": "walls", "data": [1,1,1, 1,0,1, 1,1,1]}`
+	tmj := func(layers ...string) string {
+		return `{"width": 3, "height": 3, "tilewidth": 16, "tileheight": 16, ` + tileset +
+			`, "layers": [` + strings.Join(layers, ", ") + `]}`
+	}
+
+	tests := []struct {
+		name, file, data, want string
+	}{
+		{"infinite", "map.tmj", `{"width": 3, "height": 3, "tilewidth": 16, "tileheight": 16, "infinite": true}`, "infinite maps are not supported"},
+		{"tile size", "map.tmj", `{"width": 3, "height": 3, "tilewidth": 0, "tileheight": 16}`, "invalid tile size 0x16"},
+		{"no walls", "map.tmj", tmj(spawn), "no levels defined"},
+		{"layer size", "map.tmj", tmj(`{"type": "tilelayer", "name": "walls", "data": [1, 1]}`, spawn), `tile layer "walls" has 2 tiles, expected 9`},
+		{"compression", "map.tmj", tmj(`{"type": "tilelayer", "name": "walls", "encoding": "base64", "compression": "zstd", "data": ""}`), `unsupported tile layer compression "zstd"`},
+		{"no spawn", "map.tmj", tmj(walls), "no player spawn declared"},
+		{"spawn in wall", "map.tmj", tmj(walls, `{"type": "objectgroup", "objects": [{"type": "spawn", "x": 8, "y": 8}]}`), "player spawn at (0.5, 0.5) is inside a wall"},
+		{"object type", "map.tmj", tmj(walls, spawn, `{"type": "objectgroup", "name": "things", "objects": [{"x": 24, "y": 24}]}`), `object layer "things": object at (24, 24) has no type or name`},
+		{"property value", "map.tmj", tmj(walls, `{"type": "objectgroup", "objects": [{"type": "spawn", "name": "start", "x": 24, "y": 24, "properties": [{"name": "angle", "value": "north"}]}]}`), `object "start" property "angle"`},
+		{"door off wall", "map.tmj", tmj(walls, spawn, `{"type": "objectgroup", "objects": [{"type": "door", "x": 24, "y": 24}]}`), "door at (1, 1) has no wall texture on level 0"},
+		{"hazard", "map.tmj", tmj(walls, spawn, `{"type": "tilelayer", "name": "hazards", "data": [0,0,0, 0,99,0, 0,0,0]}`), "hazards has unknown hazard 99 at (1, 1)"},
+		{"tile outside tileset", "map.tmj", tmj(`{"type": "tilelayer", "name": "walls", "data": [1,1,1, 1,0,1, 1,1,3]}`, spawn), "tile 3 is outside of tileset image"},
+		{"missing tileset", "map.tmj", `{"width": 3, "height": 3, "tilewidth": 16, "tileheight": 16, "tilesets": [{"firstgid": 1, "source": "missing.tsj"}]}`, "missing.tsj"},
+		{"environment", "map.tmj", `{"width": 3, "height": 3, "tilewidth": 16, "tileheight": 16, "properties": [{"name": "environment", "value": "{\"renderDistance\": -2}"}], ` + tileset + `, "layers": [` + walls + `, ` + spawn + `]}`, "invalid render distance -2"},
+		{
+			"tmx encoding", "map.tmx",
+			`<map width="3" height="3" tilewidth="16" tileheight="16"><layer name="walls"><data encoding="hex">00</data></layer></map>`,
+			`tile layer "walls": unsupported encoding "hex"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{tt.file: {Data: []byte(tt.data)}}
+			_, err := LoadTiledMap(fsys, tt.file)
+			if err == nil {
+				t.Fatalf("LoadTiledMap() succeeded, want error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadTiledMap() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// encodeTestTiles encodes tile layer data the way Tiled does for base64 encoding, optionally zlib compressed
+func encodeTestTiles(t *testing.T, ids []uint32, compress bool) string {
+	raw := make([]byte, 4*len(ids))
+	for i, id := range ids {
+		binary.LittleEndian.PutUint32(raw[i*4:], id)
+	}
+	if compress {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		if _, err := w.Write(raw); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		raw = buf.Bytes()
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
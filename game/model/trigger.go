@@ -0,0 +1,270 @@
+package model
+
+import (
+	"fmt"
+	"math"
+)
+
+// TriggerEvent is when a trigger fires for an entity
+type TriggerEvent string
+
+const (
+	// TriggerEnter fires once when an entity enters the trigger area
+	TriggerEnter TriggerEvent = "enter"
+	// TriggerExit fires once when an entity leaves the trigger area
+	TriggerExit TriggerEvent = "exit"
+	// TriggerStay fires every Interval seconds while an entity is inside the trigger area
+	TriggerStay TriggerEvent = "stay"
+)
+
+// defaultTriggerInterval is the number of seconds between firings of stay triggers unless the map declares otherwise
+const defaultTriggerInterval = 1.0
+
+// TriggerEntity is a kind of entity that triggers can fire for
+type TriggerEntity string
+
+const (
+	TriggerPlayer     TriggerEntity = "player"
+	TriggerSprite     TriggerEntity = "sprite"
+	TriggerProjectile TriggerEntity = "projectile"
+)
+
+// TriggerActionType is what a trigger action does when the trigger fires
+type TriggerActionType string
+
+const (
+	// ActionDoor unlocks and opens the door at the action position
+	ActionDoor TriggerActionType = "door"
+	// ActionSpawn places a sprite of the action sprite type at the action position
+	ActionSpawn TriggerActionType = "spawn"
+	// ActionEffect plays an effect of the action effect type at the action position
+	ActionEffect TriggerActionType = "effect"
+	// ActionLighting changes the global illumination and light falloff
+	ActionLighting TriggerActionType = "lighting"
+	// ActionEnd ends the level
+	ActionEnd TriggerActionType = "end"
+	// ActionTeleport moves the entity the trigger fired for to the action position
+	ActionTeleport TriggerActionType = "teleport"
+	// ActionExit leaves the map for the action level file
+	ActionExit TriggerActionType = "exit"
+)
+
+// TriggerAction is something done when a trigger fires
+type TriggerAction struct {
+	Type TriggerActionType
+	// X, Y, Z is the position of the door cell, where to place the sprite or effect, or where to teleport to
+	X, Y, Z float64
+	// Angle is the heading angle (in radians) to teleport to (nil to keep)
+	Angle *float64
+	// Sprite is the sprite type to place for spawn actions
+	Sprite string
+	// Effect is the effect type to play for effect actions
+	Effect string
+	// Illumination and Falloff are the lighting to change to for lighting actions (nil to keep)
+	Illumination *float64
+	Falloff      *float64
+	// Level is the path of the level file to exit to, relative to the current level file
+	Level string
+}
+
+// Trigger is a rectangular area of the map that fires its actions when entities enter, leave or stay in it
+type Trigger struct {
+	// X, Y is the top left corner of the trigger area in map positions, with its Width and Height
+	X, Y          float64
+	Width, Height float64
+	// Event is when the trigger fires
+	Event TriggerEvent
+	// Entities are the kinds of entities the trigger fires for
+	Entities []TriggerEntity
+	// Once makes the trigger only fire the first time
+	Once bool
+	// Interval is the number of seconds between firings of a stay trigger (0 to fire every update)
+	Interval float64
+	Actions  []TriggerAction
+
+	inside map[*Entity]struct{}
+	fired  bool
+	// cooldown is the number of seconds until a stay trigger can fire again
+	cooldown float64
+}
+
+// NewTrigger creates a trigger for the area firing for the player entering it
+func NewTrigger(x, y, width, height float64) *Trigger {
+	t := &Trigger{
+		X: x, Y: y,
+		Width: width, Height: height,
+		Event:    TriggerEnter,
+		Entities: []TriggerEntity{TriggerPlayer},
+		Interval: defaultTriggerInterval,
+		inside:   make(map[*Entity]struct{}),
+	}
+	return t
+}
+
+// Contains returns true if the map position is inside the trigger area
+func (t *Trigger) Contains(x, y float64) bool {
+	return x >= t.X && x < t.X+t.Width && y >= t.Y && y < t.Y+t.Height
+}
+
+// FiresFor returns true if the trigger fires for the kind of entity
+func (t *Trigger) FiresFor(kind TriggerEntity) bool {
+	for _, e := range t.Entities {
+		if e == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Update checks which of the entities are inside the trigger area since the last update, returning the
+// entities to fire the trigger for, with the number of seconds elapsed counting toward the stay interval.
+// Entities that were inside but are no longer given (such as removed sprites) are forgotten without firing.
+func (t *Trigger) Update(entities []*Entity, dt float64) []*Entity {
+	fired := []*Entity{}
+	fire := func(entity *Entity) {
+		if t.Once && t.fired {
+			return
+		}
+		t.fired = true
+		fired = append(fired, entity)
+	}
+
+	// all entities inside a stay trigger fire together, then wait for the interval
+	t.cooldown -= dt
+	stayReady := t.cooldown <= 0
+	stay := func(entity *Entity) {
+		if stayReady {
+			fire(entity)
+			t.cooldown = t.Interval
+		}
+	}
+
+	checked := make(map[*Entity]struct{}, len(entities))
+	for _, entity := range entities {
+		checked[entity] = struct{}{}
+
+		_, wasInside := t.inside[entity]
+		isInside := t.Contains(entity.Position.X, entity.Position.Y)
+		switch {
+		case isInside && !wasInside:
+			t.inside[entity] = struct{}{}
+			if t.Event == TriggerEnter {
+				fire(entity)
+			} else if t.Event == TriggerStay {
+				stay(entity)
+			}
+		case isInside && t.Event == TriggerStay:
+			stay(entity)
+		case !isInside && wasInside:
+			delete(t.inside, entity)
+			if t.Event == TriggerExit {
+				fire(entity)
+			}
+		}
+	}
+
+	for entity := range t.inside {
+		if _, ok := checked[entity]; !ok {
+			delete(t.inside, entity)
+		}
+	}
+
+	return fired
+}
+
+// validate makes sure the trigger is within the map and its actions can be done
+func (t *Trigger) validate(m *Map) error {
+	worldMap := m.Level(0)
+	width, height := float64(len(worldMap)), float64(len(worldMap[0]))
+
+	if t.Width <= 0 || t.Height <= 0 {
+		return fmt.Errorf("trigger at (%v, %v) has invalid size %vx%v", t.X, t.Y, t.Width, t.Height)
+	}
+	if t.X < 0 || t.Y < 0 || t.X+t.Width > width || t.Y+t.Height > height {
+		return fmt.Errorf("trigger at (%v, %v) is outside the map", t.X, t.Y)
+	}
+
+	switch t.Event {
+	case TriggerEnter, TriggerExit, TriggerStay:
+	default:
+		return fmt.Errorf("trigger at (%v, %v) has unknown event %q", t.X, t.Y, t.Event)
+	}
+	if t.Interval < 0 {
+		return fmt.Errorf("trigger at (%v, %v) has negative interval %v", t.X, t.Y, t.Interval)
+	}
+
+	if len(t.Entities) == 0 {
+		return fmt.Errorf("trigger at (%v, %v) has no entities to fire for", t.X, t.Y)
+	}
+	for _, e := range t.Entities {
+		switch e {
+		case TriggerPlayer, TriggerSprite, TriggerProjectile:
+		default:
+			return fmt.Errorf("trigger at (%v, %v) has unknown entity %q", t.X, t.Y, e)
+		}
+	}
+
+	if len(t.Actions) == 0 {
+		return fmt.Errorf("trigger at (%v, %v) has no actions", t.X, t.Y)
+	}
+	for _, a := range t.Actions {
+		positioned := a.Type != ActionLighting && a.Type != ActionEnd && a.Type != ActionExit
+		if positioned && (a.X < 0 || a.X >= width || a.Y < 0 || a.Y >= height) {
+			return fmt.Errorf("trigger at (%v, %v) has %s action at (%v, %v) outside the map", t.X, t.Y, a.Type, a.X, a.Y)
+		}
+
+		switch a.Type {
+		case ActionDoor:
+			if m.DoorAt(int(a.X), int(a.Y)) == nil {
+				return fmt.Errorf("trigger at (%v, %v) has door action at (%v, %v) without a door", t.X, t.Y, a.X, a.Y)
+			}
+		case ActionSpawn:
+			if a.Sprite == "" {
+				return fmt.Errorf("trigger at (%v, %v) has spawn action without a sprite type", t.X, t.Y)
+			}
+		case ActionEffect:
+			if a.Effect == "" {
+				return fmt.Errorf("trigger at (%v, %v) has effect action without an effect type", t.X, t.Y)
+			}
+		case ActionLighting:
+			if a.Illumination == nil && a.Falloff == nil {
+				return fmt.Errorf("trigger at (%v, %v) has lighting action without illumination or falloff", t.X, t.Y)
+			}
+		case ActionTeleport:
+			if a.Z < 0 {
+				return fmt.Errorf("trigger at (%v, %v) has teleport action to (%v, %v) below the floor", t.X, t.Y, a.X, a.Y)
+			}
+			// the height of sprites and projectiles is not known here, so only the player gets checked for headroom
+			height := 0.0
+			if t.FiresFor(TriggerPlayer) {
+				height = PlayerHeight
+			}
+			bottom, top := teleportLevels(a.Z, height)
+			for levelNum := bottom; levelNum <= top && levelNum < m.NumLevels(); levelNum++ {
+				if m.Level(levelNum)[int(a.X)][int(a.Y)] > 0 {
+					return fmt.Errorf("trigger at (%v, %v) has teleport action to (%v, %v) inside a wall on level %d", t.X, t.Y, a.X, a.Y, levelNum)
+				}
+			}
+		case ActionExit:
+			if a.Level == "" {
+				return fmt.Errorf("trigger at (%v, %v) has exit action without a level file", t.X, t.Y)
+			}
+		case ActionEnd:
+		default:
+			return fmt.Errorf("trigger at (%v, %v) has unknown action %q", t.X, t.Y, a.Type)
+		}
+	}
+
+	return nil
+}
+
+// teleportLevels returns the range of wall levels an entity of the height reaches when teleported to Z,
+// from the level its bottom is in up to the level its top reaches into
+func teleportLevels(z, height float64) (int, int) {
+	bottom := int(math.Floor(z))
+	top := int(math.Ceil(z+height)) - 1
+	if top < bottom {
+		top = bottom
+	}
+	return bottom, top
+}
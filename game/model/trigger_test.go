@@ -0,0 +1,233 @@
+package model
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/harbdog/raycaster-go/geom"
+)
+
+// triggerStep places the named entities inside or outside of the trigger area for an update,
+// entities named in neither are not given to the update
+type triggerStep struct {
+	inside, outside []string
+	dt              float64
+	want            []string
+}
+
+func TestTriggerUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger func(tr *Trigger)
+		steps   []triggerStep
+	}{
+		{
+			name: "enter",
+			steps: []triggerStep{
+				{outside: []string{"a"}},
+				{inside: []string{"a"}, want: []string{"a"}},
+				{inside: []string{"a"}},
+				{outside: []string{"a"}},
+				{inside: []string{"a", "b"}, want: []string{"a", "b"}},
+			},
+		},
+		{
+			name:    "exit",
+			trigger: func(tr *Trigger) { tr.Event = TriggerExit },
+			steps: []triggerStep{
+				{inside: []string{"a", "b"}},
+				{inside: []string{"b"}, outside: []string{"a"}, want: []string{"a"}},
+				{outside: []string{"a", "b"}, want: []string{"b"}},
+				{outside: []string{"a", "b"}},
+			},
+		},
+		{
+			name:    "removed entity does not exit",
+			trigger: func(tr *Trigger) { tr.Event = TriggerExit },
+			steps: []triggerStep{
+				{inside: []string{"a", "b"}},
+				{inside: []string{"b"}},
+				{inside: []string{"a", "b"}},
+				{outside: []string{"a", "b"}, want: []string{"a", "b"}},
+			},
+		},
+		{
+			name:    "removed entity enters again",
+			trigger: func(tr *Trigger) { tr.Event = TriggerEnter },
+			steps: []triggerStep{
+				{inside: []string{"a"}, want: []string{"a"}},
+				{},
+				{inside: []string{"a"}, want: []string{"a"}},
+			},
+		},
+		{
+			name:    "stay",
+			trigger: func(tr *Trigger) { tr.Event = TriggerStay },
+			steps: []triggerStep{
+				{inside: []string{"a"}, dt: 0.5, want: []string{"a"}},
+				{inside: []string{"a", "b"}, dt: 0.5},
+				{inside: []string{"a", "b"}, dt: 0.5, want: []string{"a", "b"}},
+				{inside: []string{"a"}, outside: []string{"b"}, dt: 1, want: []string{"a"}},
+				{outside: []string{"a", "b"}, dt: 1},
+			},
+		},
+		{
+			name:    "stay every update",
+			trigger: func(tr *Trigger) { tr.Event = TriggerStay; tr.Interval = 0 },
+			steps: []triggerStep{
+				{inside: []string{"a"}, dt: 0.1, want: []string{"a"}},
+				{inside: []string{"a"}, dt: 0.1, want: []string{"a"}},
+			},
+		},
+		{
+			name:    "once",
+			trigger: func(tr *Trigger) { tr.Once = true },
+			steps: []triggerStep{
+				{inside: []string{"a"}, want: []string{"a"}},
+				{outside: []string{"a"}},
+				{inside: []string{"a", "b"}},
+			},
+		},
+		{
+			name:    "stay once",
+			trigger: func(tr *Trigger) { tr.Event = TriggerStay; tr.Once = true },
+			steps: []triggerStep{
+				{inside: []string{"a"}, dt: 1, want: []string{"a"}},
+				{inside: []string{"a"}, dt: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTrigger(1, 1, 1, 1)
+			if tt.trigger != nil {
+				tt.trigger(tr)
+			}
+
+			entities := map[string]*Entity{"a": {Position: &geom.Vector2{}}, "b": {Position: &geom.Vector2{}}}
+			names := make(map[*Entity]string, len(entities))
+			for name, entity := range entities {
+				names[entity] = name
+			}
+
+			for i, step := range tt.steps {
+				given := []*Entity{}
+				for _, name := range step.inside {
+					entities[name].Position.X, entities[name].Position.Y = 1.5, 1.5
+					given = append(given, entities[name])
+				}
+				for _, name := range step.outside {
+					entities[name].Position.X, entities[name].Position.Y = 3.5, 3.5
+					given = append(given, entities[name])
+				}
+
+				got := []string{}
+				for _, entity := range tr.Update(given, step.dt) {
+					got = append(got, names[entity])
+				}
+				sort.Strings(got)
+				want := step.want
+				if want == nil {
+					want = []string{}
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("step %d: fired for %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTriggerValidate(t *testing.T) {
+	m, err := ParseMap([]byte(`{
+		"levels": [` + testRoom + `, [[0,0,0,0],[0,0,0,0],[0,0,7,0],[0,0,0,0]]],
+		"doors": [{"x": 3, "y": 1}],
+		"spawn": {"x": 1.5, "y": 1.5}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zero := 0.0
+	tests := []struct {
+		name    string
+		trigger func(tr *Trigger)
+		want    string
+	}{
+		{"valid", func(tr *Trigger) {}, ""},
+		{"size", func(tr *Trigger) { tr.Width = 0 }, "trigger at (1, 1) has invalid size 0x1"},
+		{"outside", func(tr *Trigger) { tr.Height = 4 }, "trigger at (1, 1) is outside the map"},
+		{"event", func(tr *Trigger) { tr.Event = "touch" }, `trigger at (1, 1) has unknown event "touch"`},
+		{"interval", func(tr *Trigger) { tr.Interval = -1 }, "trigger at (1, 1) has negative interval -1"},
+		{"no entities", func(tr *Trigger) { tr.Entities = nil }, "trigger at (1, 1) has no entities to fire for"},
+		{"entity", func(tr *Trigger) { tr.Entities = []TriggerEntity{"door"} }, `trigger at (1, 1) has unknown entity "door"`},
+		{"no actions", func(tr *Trigger) { tr.Actions = nil }, "trigger at (1, 1) has no actions"},
+		{
+			"action outside",
+			func(tr *Trigger) { tr.Actions = []TriggerAction{{Type: ActionEffect, Effect: "debris", X: 4, Y: 1}} },
+			"trigger at (1, 1) has effect action at (4, 1) outside the map",
+		},
+		{"door", func(tr *Trigger) { tr.Actions = []TriggerAction{{Type: ActionDoor, X: 3, Y: 1}} }, ""},
+		{"no door", func(tr *Trigger) { tr.Actions = []TriggerAction{{Type: ActionDoor, X: 2, Y: 1}} }, "door action at (2, 1) without a door"},
+		{"spawn", func(tr *Trigger) { tr.Actions = []TriggerAction{{Type: ActionSpawn, X: 2, Y: 1}} }, "spawn action without a sprite type"},
+		{"effect", func(tr *Trigger) { tr.Actions = []TriggerAction{{Type: ActionEffect, X: 2, Y: 1}} }, "effect action without an effect type"},
+		{"lighting", func(tr *Trigger) { tr.Actions = []TriggerAction{{Type: ActionLighting}} }, "lighting action without illumination or falloff"},
+		{"lighting falloff", func(tr *Trigger) { tr.Actions = []TriggerAction{{Type: ActionLighting, Falloff: &zero}} }, ""},
+		{"exit", func(tr *Trigger) { tr.Actions = []TriggerAction{{Type: ActionExit}} }, "exit action without a level file"},
+		{"unknown action", func(tr *Trigger) { tr.Actions = []TriggerAction{{Type: "explode"}} }, `unknown action "explode"`},
+		{"teleport", func(tr *Trigger) { tr.Actions = []TriggerAction{{Type: ActionTeleport, X: 2.5, Y: 2.5}} }, ""},
+		{
+			"teleport below the floor",
+			func(tr *Trigger) { tr.Actions = []TriggerAction{{Type: ActionTeleport, X: 1.5, Y: 1.5, Z: -1}} },
+			"teleport action to (1.5, 1.5) below the floor",
+		},
+		{
+			"teleport into wall",
+			func(tr *Trigger) { tr.Actions = []TriggerAction{{Type: ActionTeleport, X: 0.5, Y: 1.5}} },
+			"teleport action to (0.5, 1.5) inside a wall on level 0",
+		},
+		{
+			"teleport player without headroom",
+			func(tr *Trigger) { tr.Actions = []TriggerAction{{Type: ActionTeleport, X: 2.5, Y: 2.5, Z: 0.75}} },
+			"teleport action to (2.5, 2.5) inside a wall on level 1",
+		},
+		{
+			"teleport sprite below a wall",
+			func(tr *Trigger) {
+				tr.Entities = []TriggerEntity{TriggerSprite}
+				tr.Actions = []TriggerAction{{Type: ActionTeleport, X: 2.5, Y: 2.5, Z: 0.75}}
+			},
+			"",
+		},
+		{
+			"teleport on top of a wall",
+			func(tr *Trigger) { tr.Actions = []TriggerAction{{Type: ActionTeleport, X: 2.5, Y: 2.5, Z: 2}} },
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTrigger(1, 1, 1, 1)
+			tr.Actions = []TriggerAction{{Type: ActionEnd}}
+			tt.trigger(tr)
+
+			err := tr.validate(m)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("validate() error = %q, want none", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("validate() succeeded, want error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("validate() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -13,14 +13,14 @@ import (
 type Weapon struct {
 	*Sprite
 	firing             bool
-	cooldown           int
+	cooldown           float64
 	rateOfFire         float64
 	projectileVelocity float64
 	projectile         Projectile
 }
 
 func NewAnimatedWeapon(
-	x, y, scale float64, animationRate int, img *ebiten.Image, columns, rows int, projectile Projectile, projectileVelocity, rateOfFire float64,
+	x, y, scale, animationRate float64, img *ebiten.Image, columns, rows int, projectile Projectile, projectileVelocity, rateOfFire float64,
 ) *Weapon {
 	mapColor := color.RGBA{0, 0, 0, 0}
 	w := &Weapon{
@@ -35,8 +35,9 @@ func NewAnimatedWeapon(
 
 func (w *Weapon) Fire() bool {
 	if w.cooldown <= 0 {
-		// TODO: handle rate of fire greater than 60 per second?
-		w.cooldown = int(1 / w.rateOfFire * float64(ebiten.TPS()))
+		// TODO: handle rate of fire greater than one per update?
+		// adding to what is left of the cooldown keeps the rate of fire when updates are late
+		w.cooldown += 1 / w.rateOfFire
 
 		if !w.firing {
 			w.firing = true
@@ -60,8 +61,8 @@ func (w *Weapon) SpawnProjectile(x, y, z, angle, pitch float64, spawnedBy *Entit
 	p.Angle = angle
 	p.Pitch = pitch
 
-	// convert velocity from distance/second to distance per tick
-	p.Velocity = w.projectileVelocity / float64(ebiten.TPS())
+	// projectiles keep the velocity as distance/second, moving by the time elapsed each update
+	p.Velocity = w.projectileVelocity
 
 	// keep track of what spawned it
 	p.Parent = spawnedBy
@@ -77,12 +78,13 @@ func (w *Weapon) ResetCooldown() {
 	w.cooldown = 0
 }
 
-func (w *Weapon) Update() {
+// Update counts down the cooldown and plays the firing animation by the number of seconds elapsed
+func (w *Weapon) Update(dt float64) {
 	if w.cooldown > 0 {
-		w.cooldown -= 1
+		w.cooldown -= dt
 	}
 	if w.firing && w.Sprite.LoopCounter() < 1 {
-		w.Sprite.Update(nil)
+		w.Sprite.Update(nil, dt)
 	} else {
 		w.firing = false
 		w.Sprite.ResetAnimation()
@@ -0,0 +1,36 @@
+package game
+
+import (
+	"fmt"
+)
+
+// updateReload reloads the level file and textures if they changed since the last update
+func (g *Game) updateReload() {
+	select {
+	case <-g.reload:
+		g.reloadResources()
+	default:
+	}
+}
+
+// reloadResources reloads the level file and textures in place, keeping the current map and textures
+// if they cannot be loaded (e.g. while a file has not been completely saved yet).
+// The player keeps its position if it is still valid in the reloaded map, otherwise it is moved to the spawn.
+func (g *Game) reloadResources() {
+	fmt.Printf("Reloading map and textures\n")
+
+	// generated maps have no level file to reload
+	mapObj, mapFS := g.mapObj, g.levelFS
+	if g.mapGenerate.Algorithm == "" {
+		var err error
+		mapObj, mapFS, err = g.loadMap()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	if err := g.loadLevel(mapObj, mapFS, true); err != nil {
+		fmt.Println(err)
+	}
+}
@@ -2,143 +2,327 @@ package game
 
 import (
 	"embed"
+	"fmt"
 	"image"
 	"image/color"
+	"io/fs"
 	"log"
+	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/harbdog/raycaster-go"
 	"github.com/harbdog/raycaster-go-demo/game/model"
-	"github.com/harbdog/raycaster-go/geom"
 )
 
 //go:embed resources
 var embedded embed.FS
 
+// textureManifest is the texture manifest declaring the textures loaded by name, relative to the resources
+const textureManifest = "textures.json"
+
+// archetypeManifest is the manifest declaring the sprite archetypes created by sprite type, relative to the resources
+const archetypeManifest = "archetypes.json"
+
+// defaultLevel is the level loaded if no level file is configured, relative to the resources
+const defaultLevel = "levels/default.json"
+
+// resourcesFS returns the file system of the game resources, which are embedded unless a resources path
+// is configured to load them from disk instead (e.g. to see changes to textures while the game runs)
+func (g *Game) resourcesFS() fs.FS {
+	if g.resourcesPath != "" {
+		return os.DirFS(g.resourcesPath)
+	}
+
+	resources, err := fs.Sub(embedded, "resources")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return resources
+}
+
 // loadContent will be called once per game and is the place to load
 // all of your content.
-func (g *Game) loadContent() {
-
-	// TODO: make resource management better
-
-	// load wall textures
-	g.tex.textures[0] = getTextureFromFile("stone.png")
-	g.tex.textures[1] = getTextureFromFile("left_bot_house.png")
-	g.tex.textures[2] = getTextureFromFile("right_bot_house.png")
-	g.tex.textures[3] = getTextureFromFile("left_top_house.png")
-	g.tex.textures[4] = getTextureFromFile("right_top_house.png")
-	g.tex.textures[5] = getTextureFromFile("ebitengine_splash.png")
-
-	// separating sprites out a bit from wall textures
-	g.tex.textures[8] = getSpriteFromFile("large_rock.png")
-	g.tex.textures[9] = getSpriteFromFile("tree_09.png")
-	g.tex.textures[10] = getSpriteFromFile("tree_10.png")
-	g.tex.textures[14] = getSpriteFromFile("tree_14.png")
-
-	// load texture sheets
-	g.tex.textures[15] = getSpriteFromFile("sorcerer_sheet.png")
-	g.tex.textures[16] = getSpriteFromFile("crosshairs_sheet.png")
-	g.tex.textures[17] = getSpriteFromFile("charged_bolt_sheet.png")
-	g.tex.textures[18] = getSpriteFromFile("blue_explosion_sheet.png")
-	g.tex.textures[19] = getSpriteFromFile("outleader_walking_sheet.png")
-	g.tex.textures[20] = getSpriteFromFile("hand_spell.png")
-	g.tex.textures[21] = getSpriteFromFile("hand_staff.png")
-	g.tex.textures[22] = getSpriteFromFile("red_bolt.png")
-	g.tex.textures[23] = getSpriteFromFile("red_explosion_sheet.png")
-	g.tex.textures[24] = getSpriteFromFile("bat_sheet.png")
-
-	// just setting the grass texture apart from the rest since it gets special handling
-	if g.debug {
-		g.tex.floorTex = getRGBAFromFile("grass_debug.png")
-	} else {
-		g.tex.floorTex = getRGBAFromFile("grass.png")
+func (g *Game) loadContent(mapFS fs.FS) {
+	err := g.loadTextures(g.tex, mapFS)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g.spriteFactory, err = g.loadArchetypes(g.tex)
+	if err != nil {
+		log.Fatal(err)
 	}
 }
 
-func newImageFromFile(path string) (*ebiten.Image, image.Image, error) {
-	f, err := embedded.Open(filepath.ToSlash(path))
+// loadArchetypes loads the archetype manifest into a factory creating sprites with the textures
+func (g *Game) loadArchetypes(tex *TextureHandler) (*model.SpriteFactory, error) {
+	manifest, err := model.LoadArchetypes(g.resources, archetypeManifest)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	defer f.Close()
-	eb, im, err := ebitenutil.NewImageFromReader(f)
-	return eb, im, err
+	return model.NewSpriteFactory(manifest, tex)
 }
 
-func newScaledImageFromFile(path string, scale float64) (*ebiten.Image, image.Image, error) {
-	eb, im, err := newImageFromFile(path)
+// loadTextures loads the textures of the texture manifest into the texture handler,
+// then the wall textures declared by its map over them from the map file system
+func (g *Game) loadTextures(tex *TextureHandler, mapFS fs.FS) error {
+	manifest, err := model.LoadTextureManifest(g.resources, textureManifest)
 	if err != nil {
-		return eb, im, err
+		return err
 	}
 
-	if scale == 1.0 {
-		return eb, im, err
+	for _, entry := range manifest.Textures {
+		err = loadTexture(tex, g.resources, entry)
+		if err != nil {
+			return err
+		}
 	}
 
-	op := &ebiten.DrawImageOptions{}
-	op.Filter = ebiten.FilterNearest
-	op.GeoM.Scale(scale, scale)
+	// just setting the floor texture of the map environment apart from the rest since it gets special handling
+	env := g.mapEnvironment(tex.mapObj)
+	tex.floorTex = tex.FloorTextureByName(env.Floor)
+	if tex.floorTex == nil {
+		return fmt.Errorf("floor texture %q is not declared in %s", env.Floor, textureManifest)
+	}
+	if tex.TextureByName(env.Sky) == nil {
+		return fmt.Errorf("sky texture %q is not declared in %s", env.Sky, textureManifest)
+	}
 
-	scaledWidth, scaledHeight := float64(eb.Bounds().Dx())*scale, float64(eb.Bounds().Dy())*scale
-	scaledImage := ebiten.NewImage(int(scaledWidth), int(scaledHeight))
-	scaledImage.DrawImage(eb, op)
+	err = loadMapTextures(tex, mapFS)
+	if err != nil {
+		return err
+	}
+	tex.loadDoorTextures()
+	return nil
+}
 
-	return scaledImage, scaledImage, err
+// loadTexture loads a texture declared by the texture manifest, wall textures are also
+// set for their wall texture number so maps can use them for walls and floors
+func loadTexture(tex *TextureHandler, fsys fs.FS, entry model.TextureEntry) error {
+	eImg, img, err := newImageFromFS(fsys, entry.File)
+	if err != nil {
+		return fmt.Errorf("unable to load texture %q: %w", entry.Name, err)
+	}
+
+	switch entry.Kind {
+	case model.TextureWall:
+		if entry.IsAnimated() {
+			frames, frameRect := newAnimationFrames(eImg, entry)
+			eImg = frames[0]
+			tex.SetAnimatedTexture(entry.ID-1, frames, entry.Rate)
+			tex.SetFloorTexture(entry.ID-1, newFloorTexture(img, frameRect))
+			break
+		}
+		eImg = newWallTexture(eImg)
+		tex.SetTexture(entry.ID-1, eImg)
+		tex.SetFloorTexture(entry.ID-1, newFloorTexture(img, img.Bounds()))
+	case model.TextureFloor:
+		tex.SetNamedFloorTexture(entry.Name, newFloorTexture(img, img.Bounds()))
+	case model.TextureSheet:
+		if entry.Data != "" {
+			sheet, err := model.LoadSpriteSheet(fsys, entry.Data)
+			if err != nil {
+				return fmt.Errorf("unable to load texture %q: %w", entry.Name, err)
+			}
+			tex.SetSpriteSheet(entry.Name, sheet)
+		}
+	}
+
+	tex.SetNamedTexture(entry.Name, eImg)
+	return nil
+}
+
+// getTexture returns the texture with the given name from the texture manifest
+func (g *Game) getTexture(name string) *ebiten.Image {
+	tex := g.tex.TextureByName(name)
+	if tex == nil {
+		log.Fatalf("texture %q is not declared in %s", name, textureManifest)
+	}
+	return tex
+}
+
+// getSpriteSheet returns the frame layout of the sheet texture with the given name from the texture manifest
+func (g *Game) getSpriteSheet(name string) *model.SpriteSheet {
+	sheet := g.tex.SpriteSheetByName(name)
+	if sheet == nil {
+		log.Fatalf("texture %q is not declared with sprite sheet data in %s", name, textureManifest)
+	}
+	return sheet
+}
+
+// loadMap loads the current level file, or generates a map if a generator algorithm is configured.
+// Also returns the file system of the level file so files it refers to can be loaded relative to it
+// (nil for generated maps).
+func (g *Game) loadMap() (*model.Map, fs.FS, error) {
+	if g.mapGenerate.Algorithm != "" {
+		mapObj, err := g.generateMap()
+		return mapObj, nil, err
+	}
+
+	mapObj, err := model.LoadMap(g.levelFS, g.levelPath)
+	return mapObj, g.levelFS, err
 }
 
-func getRGBAFromFile(texFile string) *image.RGBA {
-	var rgba *image.RGBA
-	_, tex, err := newImageFromFile("resources/textures/" + texFile)
+// levelFile returns the file system and the path within it of the configured level file,
+// or of the default level of the resources if no level file is configured
+func (g *Game) levelFile() (fs.FS, string, error) {
+	if g.mapFile == "" {
+		return g.resources, defaultLevel, nil
+	}
+	return model.MapFileFS(g.mapFile)
+}
+
+// generateMap generates a map using the configured generator, picking the wall textures by name from the texture manifest
+func (g *Game) generateMap() (*model.Map, error) {
+	manifest, err := model.LoadTextureManifest(g.resources, textureManifest)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	if tex != nil {
-		rgba = image.NewRGBA(image.Rect(0, 0, texWidth, texWidth))
-		// convert into RGBA format
-		for x := 0; x < texWidth; x++ {
-			for y := 0; y < texWidth; y++ {
-				clr := tex.At(x, y).(color.RGBA)
-				rgba.SetRGBA(x, y, clr)
+
+	cfg := g.mapGenerate
+	cfg.WallTextures = []int{}
+	for _, name := range g.mapGenerateTextures {
+		found := false
+		for _, entry := range manifest.Textures {
+			if entry.Name == name && entry.Kind == model.TextureWall {
+				cfg.WallTextures = append(cfg.WallTextures, entry.ID)
+				found = true
+				break
 			}
 		}
+		if !found {
+			return nil, fmt.Errorf("generated map texture %q is not a wall texture in %s", name, textureManifest)
+		}
+	}
+
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
 	}
+	// print the seed so the same map can be generated again
+	fmt.Printf("Generating %s map with seed %d\n", cfg.Algorithm, cfg.Seed)
+
+	return model.GenerateMap(cfg)
+}
 
+// loadMapTextures loads the wall textures declared by the map of the texture handler from the map file system
+func loadMapTextures(tex *TextureHandler, mapFS fs.FS) error {
+	images := make(map[string]*ebiten.Image)
+	sources := make(map[string]image.Image)
+	for _, tile := range tex.mapObj.Tiles() {
+		img, ok := images[tile.Image]
+		src := sources[tile.Image]
+		if !ok {
+			var err error
+			img, src, err = newImageFromFS(mapFS, tile.Image)
+			if err != nil {
+				return fmt.Errorf("unable to load map texture %d: %w", tile.ID, err)
+			}
+			images[tile.Image] = img
+			sources[tile.Image] = src
+		}
+
+		texNum := tile.ID - 1
+		srcRect := src.Bounds()
+		if !tile.Rect.Empty() {
+			img = img.SubImage(tile.Rect).(*ebiten.Image)
+			srcRect = tile.Rect
+		}
+		tex.SetTexture(texNum, newWallTexture(img))
+		tex.SetFloorTexture(texNum, newFloorTexture(src, srcRect))
+	}
+	return nil
+}
+
+// newAnimationFrames splits the sprite sheet of an animated wall texture into its frames read row by row,
+// each sized to be rendered as a wall. Also returns the area of the first frame in the sprite sheet.
+func newAnimationFrames(img *ebiten.Image, entry model.TextureEntry) ([]*ebiten.Image, image.Rectangle) {
+	columns, rows := entry.SheetSize()
+	b := img.Bounds()
+	w, h := b.Dx()/columns, b.Dy()/rows
+
+	frames := make([]*ebiten.Image, entry.NumFrames())
+	for i := range frames {
+		x, y := b.Min.X+(i%columns)*w, b.Min.Y+(i/columns)*h
+		frames[i] = newWallTexture(img.SubImage(image.Rect(x, y, x+w, y+h)).(*ebiten.Image))
+	}
+	return frames, image.Rect(b.Min.X, b.Min.Y, b.Min.X+w, b.Min.Y+h)
+}
+
+// newWallTexture returns the image as a texture sized to be rendered as a wall
+func newWallTexture(img *ebiten.Image) *ebiten.Image {
+	b := img.Bounds()
+	if b.Min.X == 0 && b.Min.Y == 0 && b.Dx() == texWidth && b.Dy() == texWidth {
+		return img
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	op.Filter = ebiten.FilterNearest
+	op.GeoM.Scale(float64(texWidth)/float64(b.Dx()), float64(texWidth)/float64(b.Dy()))
+
+	tex := ebiten.NewImage(texWidth, texWidth)
+	tex.DrawImage(img, op)
+	return tex
+}
+
+// newFloorTexture returns the area of the image as an RGBA texture sized to be rendered as a floor
+func newFloorTexture(img image.Image, r image.Rectangle) *image.RGBA {
+	rgba := image.NewRGBA(image.Rect(0, 0, texWidth, texWidth))
+	for x := 0; x < texWidth; x++ {
+		for y := 0; y < texWidth; y++ {
+			rgba.Set(x, y, img.At(r.Min.X+x*r.Dx()/texWidth, r.Min.Y+y*r.Dy()/texWidth))
+		}
+	}
 	return rgba
 }
 
-func getTextureFromFile(texFile string) *ebiten.Image {
-	eImg, _, err := newImageFromFile("resources/textures/" + texFile)
+func newImageFromFile(path string) (*ebiten.Image, image.Image, error) {
+	return newImageFromFS(embedded, path)
+}
+
+func newImageFromFS(fsys fs.FS, path string) (*ebiten.Image, image.Image, error) {
+	f, err := fsys.Open(filepath.ToSlash(path))
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, err
 	}
-	return eImg
+	defer f.Close()
+	eb, im, err := ebitenutil.NewImageFromReader(f)
+	return eb, im, err
 }
 
-func getSpriteFromFile(sFile string) *ebiten.Image {
-	eImg, _, err := newImageFromFile("resources/sprites/" + sFile)
+func newScaledImageFromFile(path string, scale float64) (*ebiten.Image, image.Image, error) {
+	eb, im, err := newImageFromFile(path)
 	if err != nil {
-		log.Fatal(err)
+		return eb, im, err
 	}
-	return eImg
+
+	if scale == 1.0 {
+		return eb, im, err
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	op.Filter = ebiten.FilterNearest
+	op.GeoM.Scale(scale, scale)
+
+	scaledWidth, scaledHeight := float64(eb.Bounds().Dx())*scale, float64(eb.Bounds().Dy())*scale
+	scaledImage := ebiten.NewImage(int(scaledWidth), int(scaledHeight))
+	scaledImage.DrawImage(eb, op)
+
+	return scaledImage, scaledImage, err
 }
 
 func (g *Game) loadSprites() {
 	g.projectiles = make(map[*model.Projectile]struct{}, 1024)
 	g.effects = make(map[*model.Effect]struct{}, 1024)
-	g.sprites = make(map[*model.Sprite]struct{}, 128)
 
 	// colors for minimap representation
 	blueish := color.RGBA{62, 62, 100, 96}
 	reddish := color.RGBA{180, 62, 62, 96}
-	brown := color.RGBA{47, 40, 30, 196}
-	green := color.RGBA{27, 37, 7, 196}
-	orange := color.RGBA{69, 30, 5, 196}
-	yellow := color.RGBA{255, 200, 0, 196}
 
 	// preload projectile sprites
-	chargedBoltImg := g.tex.textures[17]
+	chargedBoltImg := g.getTexture("charged_bolt_sheet")
 	chargedBoltWidth := chargedBoltImg.Bounds().Dx()
 	chargedBoltCols, chargedBoltRows := 6, 1
 	chargedBoltScale := 0.3
@@ -147,11 +331,11 @@ func (g *Game) loadSprites() {
 	chargedBoltCollisionRadius := (chargedBoltScale * chargedBoltPxRadius) / (float64(chargedBoltWidth) / float64(chargedBoltCols))
 	chargedBoltCollisionHeight := 2 * chargedBoltCollisionRadius
 	chargedBoltProjectile := model.NewAnimatedProjectile(
-		0, 0, chargedBoltScale, 1, chargedBoltImg, blueish,
+		0, 0, chargedBoltScale, 30, chargedBoltImg, blueish,
 		chargedBoltCols, chargedBoltRows, raycaster.AnchorCenter, chargedBoltCollisionRadius, chargedBoltCollisionHeight,
 	)
 
-	redBoltImg := g.tex.textures[22]
+	redBoltImg := g.getTexture("red_bolt")
 	redBoltWidth := redBoltImg.Bounds().Dx()
 	redBoltScale := 0.25
 	// in pixels, radius to use for collision testing
@@ -164,190 +348,133 @@ func (g *Game) loadSprites() {
 	)
 
 	// preload effect sprites
-	blueExplosionEffect := model.NewAnimatedEffect(
-		0, 0, 0.75, 3, g.tex.textures[18], 5, 3, raycaster.AnchorCenter, 1,
-	)
+	blueExplosionEffect, err := g.newEffectByType("blue_explosion", 0, 0, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
 	chargedBoltProjectile.ImpactEffect = *blueExplosionEffect
 
-	redExplosionEffect := model.NewAnimatedEffect(
-		0, 0, 0.20, 1, g.tex.textures[23], 8, 3, raycaster.AnchorCenter, 1,
-	)
+	redExplosionEffect, err := g.newEffectByType("red_explosion", 0, 0, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
 	redBoltProjectile.ImpactEffect = *redExplosionEffect
 
 	// create weapons
 	chargedBoltRoF := 2.5      // Rate of Fire (as RoF/second)
 	chargedBoltVelocity := 6.0 // Velocity (as distance travelled/second)
-	chargedBoltWeapon := model.NewAnimatedWeapon(1, 1, 1.0, 7, g.tex.textures[20], 3, 1, *chargedBoltProjectile, chargedBoltVelocity, chargedBoltRoF)
+	chargedBoltWeapon := model.NewAnimatedWeapon(1, 1, 1.0, 7.5, g.getTexture("hand_spell"), 3, 1, *chargedBoltProjectile, chargedBoltVelocity, chargedBoltRoF)
 	g.player.AddWeapon(chargedBoltWeapon)
 
 	staffBoltRoF := 6.0
 	staffBoltVelocity := 24.0
-	staffBoltWeapon := model.NewAnimatedWeapon(1, 1, 1.0, 7, g.tex.textures[21], 3, 1, *redBoltProjectile, staffBoltVelocity, staffBoltRoF)
+	staffBoltWeapon := model.NewAnimatedWeapon(1, 1, 1.0, 7.5, g.getTexture("hand_staff"), 3, 1, *redBoltProjectile, staffBoltVelocity, staffBoltRoF)
 	g.player.AddWeapon(staffBoltWeapon)
 
-	// animated single facing sorcerer
-	sorcImg := g.tex.textures[15]
-	sorcWidth, sorcHeight := sorcImg.Bounds().Dx(), sorcImg.Bounds().Dy()
-	sorcCols, sorcRows := 10, 1
-	sorcScale := 1.25
-	// in pixels, radius and height to use for collision testing
-	sorcPxRadius, sorcPxHeight := 40.0, 120.0
-	// convert pixel to grid using image pixel size
-	sorcCollisionRadius := (sorcScale * sorcPxRadius) / (float64(sorcWidth) / float64(sorcCols))
-	sorcCollisionHeight := (sorcScale * sorcPxHeight) / (float64(sorcHeight) / float64(sorcRows))
-	sorc := model.NewAnimatedSprite(
-		22.5, 11.75, sorcScale, 5, sorcImg, yellow, sorcCols, sorcRows, raycaster.AnchorBottom, sorcCollisionRadius, sorcCollisionHeight,
-	)
-	// give sprite a sample velocity for movement
-	sorc.Angle = geom.Radians(180)
-	sorc.Velocity = 0.02
-	g.addSprite(sorc)
-
-	// animated walking 8-directional sprite character
-	// [walkerTexFacingMap] player facing angle : texture row index
-	var walkerTexFacingMap = map[float64]int{
-		geom.Radians(315): 0,
-		geom.Radians(270): 1,
-		geom.Radians(225): 2,
-		geom.Radians(180): 3,
-		geom.Radians(135): 4,
-		geom.Radians(90):  5,
-		geom.Radians(45):  6,
-		geom.Radians(0):   7,
-	}
-	walkerImg := g.tex.textures[19]
-	walkerWidth, walkerHeight := walkerImg.Bounds().Dx(), walkerImg.Bounds().Dy()
-	walkerCols, walkerRows := 4, 8
-	walkerScale := 0.75
-	// in pixels, radius and height to use for collision testing
-	walkerPxRadius, walkerPxHeight := 30.0, 80.0
-	// convert pixel to grid using image pixel size
-	walkerCollisionRadius := (walkerScale * walkerPxRadius) / (float64(walkerWidth) / float64(walkerCols))
-	walkerCollisionHeight := (walkerScale * walkerPxHeight) / (float64(walkerHeight) / float64(walkerRows))
-	walker := model.NewAnimatedSprite(
-		7.5, 6.0, walkerScale, 10, walkerImg, yellow, walkerCols, walkerRows, raycaster.AnchorBottom, walkerCollisionRadius, walkerCollisionHeight,
-	)
-	walker.SetAnimationReversed(true) // this sprite sheet has reversed animation frame order
-	walker.SetTextureFacingMap(walkerTexFacingMap)
-	// give sprite a sample velocity for movement
-	walker.Angle = geom.Radians(0)
-	walker.Velocity = 0.02
-	g.addSprite(walker)
-
-	// animated flying 4-directional sprite creature
-	// [batTexFacingMap] player facing angle : texture row index
-	var batTexFacingMap = map[float64]int{
-		geom.Radians(270): 1,
-		geom.Radians(180): 2,
-		geom.Radians(90):  3,
-		geom.Radians(0):   0,
-	}
-	batImg := g.tex.textures[24]
-	batWidth, batHeight := batImg.Bounds().Dx(), batImg.Bounds().Dy()
-	batCols, batRows := 3, 4
-	batScale := 0.25
-	// in pixels, radius and height to use for collision testing
-	batPxRadius, batPxHeight := 14.0, 25.0
-	// convert pixel to grid using image pixel size
-	batCollisionRadius := (batScale * batPxRadius) / (float64(batWidth) / float64(batCols))
-	batCollisionHeight := (batScale * batPxHeight) / (float64(batHeight) / float64(batRows))
-	batty := model.NewAnimatedSprite(
-		10.0, 5.0, batScale, 10, batImg, yellow, batCols, batRows, raycaster.AnchorTop, batCollisionRadius, batCollisionHeight,
-	)
-	batty.SetTextureFacingMap(batTexFacingMap)
-	// raising Z-position of sprite model but using raycaster.AnchorTop to show below that position
-	batty.PositionZ = 1.0
-	// give sprite a sample velocity for movement
-	batty.Angle = geom.Radians(150)
-	batty.Velocity = 0.03
-	g.addSprite(batty)
-
 	if g.debug {
 		// just some debugging stuff
-		sorc.AddDebugLines(2, color.RGBA{0, 255, 0, 255})
-		walker.AddDebugLines(2, color.RGBA{0, 255, 0, 255})
-		batty.AddDebugLines(2, color.RGBA{0, 255, 0, 255})
 		chargedBoltProjectile.AddDebugLines(2, color.RGBA{0, 255, 0, 255})
 		redBoltProjectile.AddDebugLines(2, color.RGBA{0, 255, 0, 255})
 	}
 
-	// rock that can be jumped over but not walked through
-	rockImg := g.tex.textures[8]
-	rockWidth, rockHeight := rockImg.Bounds().Dx(), rockImg.Bounds().Dy()
-	rockScale := 0.4
-	rockPxRadius, rockPxHeight := 24.0, 35.0
-	rockCollisionRadius := (rockScale * rockPxRadius) / float64(rockWidth)
-	rockCollisionHeight := (rockScale * rockPxHeight) / float64(rockHeight)
-	rock := model.NewSprite(8.0, 5.5, rockScale, rockImg, brown, raycaster.AnchorBottom, rockCollisionRadius, rockCollisionHeight)
-	g.addSprite(rock)
-
-	// testing sprite scaling
-	testScale := 0.5
-	g.addSprite(model.NewSprite(10.5, 2.5, testScale, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-
-	// // line of trees for testing in front of initial view
-	// Setting CollisionRadius=0 to disable collision against small trees
-	g.addSprite(model.NewSprite(19.5, 11.5, 1.0, g.tex.textures[10], brown, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(17.5, 11.5, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(15.5, 11.5, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	// // // render a forest!
-	g.addSprite(model.NewSprite(11.5, 1.5, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(12.5, 1.5, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(132.5, 1.5, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(11.5, 2, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(12.5, 2, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(13.5, 2, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(11.5, 2.5, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(12.25, 2.5, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(13.5, 2.25, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(11.5, 3, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(12.5, 3, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(13.25, 3, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(10.5, 3.5, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(11.5, 3.25, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(12.5, 3.5, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(13.25, 3.5, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(10.5, 4, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(11.5, 4, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(12.5, 4, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(13.5, 4, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(10.5, 4.5, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(11.25, 4.5, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(12.5, 4.5, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(13.5, 4.5, 1.0, g.tex.textures[10], brown, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(14.5, 4.25, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(10.5, 5, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(11.5, 5, 1.0, g.tex.textures[9], green, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(12.5, 5, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(13.25, 5, 1.0, g.tex.textures[10], brown, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(14.5, 5, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(11.5, 5.5, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(12.5, 5.25, 1.0, g.tex.textures[10], brown, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(13.5, 5.25, 1.0, g.tex.textures[10], brown, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(14.5, 5.5, 1.0, g.tex.textures[10], brown, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(15.5, 5.5, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(11.5, 6, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(12.5, 6, 1.0, g.tex.textures[10], brown, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(13.25, 6, 1.0, g.tex.textures[10], brown, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(14.25, 6, 1.0, g.tex.textures[10], brown, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(15.5, 6, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(12.5, 6.5, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(13.5, 6.25, 1.0, g.tex.textures[10], brown, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(14.5, 6.5, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(12.5, 7, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(13.5, 7, 1.0, g.tex.textures[10], brown, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(14.5, 7, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(13.5, 7.5, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
-	g.addSprite(model.NewSprite(13.5, 8, 1.0, g.tex.textures[14], orange, raycaster.AnchorBottom, 0, 0))
+	sprites, err := g.newMapSprites(g.mapObj, g.spriteFactory)
+	if err != nil {
+		log.Fatal(err)
+	}
+	g.setSprites(sprites)
+}
+
+// newMapSprites creates the sprites declared by the map with the sprite factory,
+// returning an error without creating any sprites if the map declares an unknown sprite type
+func (g *Game) newMapSprites(mapObj *model.Map, spriteFactory *model.SpriteFactory) (map[*model.Sprite]struct{}, error) {
+	sprites := make(map[*model.Sprite]struct{}, 128)
+	for _, p := range mapObj.Sprites() {
+		sprite, err := g.newSpriteFromFactory(spriteFactory, p.Type, p.X, p.Y, p.Scale)
+		if err != nil {
+			return nil, err
+		}
+		sprite.PositionZ = p.Z
+		// give sprite its velocity for movement, or keep the velocity of its archetype
+		sprite.Angle = p.Angle
+		if p.Velocity != 0 {
+			sprite.Velocity = p.Velocity
+		}
+		sprites[sprite] = struct{}{}
+	}
+	return sprites, nil
+}
+
+// newSpriteByType creates a sprite of the archetype of the given type at a map position,
+// using the default scale of the archetype if scale is 0
+func (g *Game) newSpriteByType(spriteType string, x, y, scale float64) (*model.Sprite, error) {
+	return g.newSpriteFromFactory(g.spriteFactory, spriteType, x, y, scale)
+}
+
+// newSpriteFromFactory creates a sprite of the given type with the sprite factory
+func (g *Game) newSpriteFromFactory(spriteFactory *model.SpriteFactory, spriteType string, x, y, scale float64) (*model.Sprite, error) {
+	sprite, err := spriteFactory.NewSprite(spriteType, x, y, scale)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.debug {
+		// just some debugging stuff
+		sprite.AddDebugLines(2, color.RGBA{0, 255, 0, 255})
+	}
+
+	return sprite, nil
+}
+
+// newEffectByType creates an effect of the given type at a map position
+func (g *Game) newEffectByType(effectType string, x, y, z float64) (*model.Effect, error) {
+	var effect *model.Effect
+
+	switch effectType {
+	case "blue_explosion":
+		effect = model.NewAnimatedEffect(
+			x, y, 0.75, 15, g.getTexture("blue_explosion_sheet"), 5, 3, raycaster.AnchorCenter, 1,
+		)
+
+	case "red_explosion":
+		effect = model.NewAnimatedEffect(
+			x, y, 0.20, 30, g.getTexture("red_explosion_sheet"), 8, 3, raycaster.AnchorCenter, 1,
+		)
+
+	case "debris":
+		if g.debrisSheet == nil {
+			g.debrisSheet = newDebrisSheet()
+		}
+		effect = model.NewAnimatedEffect(
+			x, y, 1.0, 15, g.debrisSheet, debrisFrames, 1, raycaster.AnchorCenter, 1,
+		)
+
+	default:
+		return nil, fmt.Errorf("unknown effect type %q", effectType)
+	}
+
+	effect.PositionZ = z
+	return effect, nil
+}
+
+// setSprites replaces the sprites, indexing them by the chunk they are in
+func (g *Game) setSprites(sprites map[*model.Sprite]struct{}) {
+	g.sprites = sprites
+	g.spriteChunks = make(map[image.Point]map[*model.Entity]struct{})
+	g.spriteChunkOf = make(map[*model.Entity]image.Point, len(sprites))
+	for sprite := range sprites {
+		g.indexSprite(sprite.Entity)
+	}
 }
 
 func (g *Game) addSprite(sprite *model.Sprite) {
 	g.sprites[sprite] = struct{}{}
+	g.indexSprite(sprite.Entity)
 }
 
-// func (g *Game) deleteSprite(sprite *model.Sprite) {
-// 	delete(g.sprites, sprite)
-// }
+func (g *Game) deleteSprite(sprite *model.Sprite) {
+	delete(g.sprites, sprite)
+	g.unindexSprite(sprite.Entity)
+}
 
 func (g *Game) addProjectile(projectile *model.Projectile) {
 	g.projectiles[projectile] = struct{}{}
@@ -8,75 +8,227 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
+// numDoorFrames is the number of frames rendered for a door sliding open
+const numDoorFrames = 16
+
 type TextureHandler struct {
-	mapObj         *model.Map
-	textures       []*ebiten.Image
+	mapObj *model.Map
+
+	// textures are the wall textures indexed by wall texture number - 1, grown as textures are set
+	textures      []*ebiten.Image
+	doorFrames    map[int][]*ebiten.Image
+	floorTextures []*image.RGBA
+
+	// animations are the animated wall textures by wall texture number - 1, advanced each tick
+	animations map[int]*wallAnimation
+	elapsed    float64
+
+	// decals are the impact decals by wall face, with the decalOrder of the faces they were added to
+	// so the oldest decals are removed first once there are maxDecals
+	decals     map[decalFace]*wallDecals
+	decalOrder []decalFace
+	decalTex   *ebiten.Image
+	maxDecals  int
+
+	// named textures are all textures from the texture manifest by name
+	named      map[string]*ebiten.Image
+	namedFloor map[string]*image.RGBA
+	// sheets are the frame layouts of sheet textures declared with sprite sheet data, by texture name
+	sheets map[string]*model.SpriteSheet
+
 	floorTex       *image.RGBA
 	renderFloorTex bool
+
+	// viewX, viewY is the position walls are viewed from, to determine which face of a wall cell is seen
+	viewX, viewY float64
 }
 
-func NewTextureHandler(mapObj *model.Map, textureCapacity int) *TextureHandler {
+func NewTextureHandler(mapObj *model.Map) *TextureHandler {
 	t := &TextureHandler{
 		mapObj:         mapObj,
-		textures:       make([]*ebiten.Image, textureCapacity),
+		named:          make(map[string]*ebiten.Image),
+		namedFloor:     make(map[string]*image.RGBA),
+		sheets:         make(map[string]*model.SpriteSheet),
+		animations:     make(map[int]*wallAnimation),
+		decals:         make(map[decalFace]*wallDecals),
 		renderFloorTex: true,
 	}
 	return t
 }
 
+// wallAnimation is an animated wall texture showing its frames in a loop at rate frames per second
+type wallAnimation struct {
+	frames []*ebiten.Image
+	rate   float64
+	frame  int
+}
+
+// SetNamedTexture sets the texture for the given texture name
+func (t *TextureHandler) SetNamedTexture(name string, img *ebiten.Image) {
+	t.named[name] = img
+}
+
+// TextureByName returns the texture with the given name (nil if there is none)
+func (t *TextureHandler) TextureByName(name string) *ebiten.Image {
+	return t.named[name]
+}
+
+// SetSpriteSheet sets the frame layout of the sheet texture with the given name
+func (t *TextureHandler) SetSpriteSheet(name string, sheet *model.SpriteSheet) {
+	t.sheets[name] = sheet
+}
+
+// SpriteSheetByName returns the frame layout of the sheet texture with the given name (nil if it has none)
+func (t *TextureHandler) SpriteSheetByName(name string) *model.SpriteSheet {
+	return t.sheets[name]
+}
+
+// SetNamedFloorTexture sets the floor texture for the given texture name
+func (t *TextureHandler) SetNamedFloorTexture(name string, img *image.RGBA) {
+	t.namedFloor[name] = img
+}
+
+// FloorTextureByName returns the floor texture with the given name (nil if there is none)
+func (t *TextureHandler) FloorTextureByName(name string) *image.RGBA {
+	return t.namedFloor[name]
+}
+
+// SetTexture sets the texture for the given texture index, growing the texture capacity if needed
+func (t *TextureHandler) SetTexture(texNum int, img *ebiten.Image) {
+	if texNum >= len(t.textures) {
+		textures := make([]*ebiten.Image, texNum+1)
+		copy(textures, t.textures)
+		t.textures = textures
+	}
+	t.textures[texNum] = img
+}
+
+// SetAnimatedTexture sets the animation frames for the given texture index, with the first frame
+// as the texture used for doors and where a still image is needed
+func (t *TextureHandler) SetAnimatedTexture(texNum int, frames []*ebiten.Image, rate float64) {
+	t.SetTexture(texNum, frames[0])
+	t.animations[texNum] = &wallAnimation{frames: frames, rate: rate}
+}
+
+// Update advances the animated wall textures by the number of seconds elapsed to their current frame,
+// along with the decals on them
+func (t *TextureHandler) Update(dt float64) {
+	t.elapsed += dt
+	for _, anim := range t.animations {
+		anim.frame = int(t.elapsed*anim.rate) % len(anim.frames)
+	}
+	t.updateDecals()
+}
+
+// SetFloorTexture sets the image used to render floors with the given texture index
+func (t *TextureHandler) SetFloorTexture(texNum int, img *image.RGBA) {
+	if texNum >= len(t.floorTextures) {
+		floorTextures := make([]*image.RGBA, texNum+1)
+		copy(floorTextures, t.floorTextures)
+		t.floorTextures = floorTextures
+	}
+	t.floorTextures[texNum] = img
+}
+
 func (t *TextureHandler) TextureAt(x, y, levelNum, side int) *ebiten.Image {
-	texNum := -1
+	if levelNum == 0 {
+		if door := t.mapObj.DoorAt(x, y); door != nil && !door.IsPassable() {
+			return t.doorTexture(door.TexNum-1, door.OpenAmount())
+		}
+	}
 
-	mapLevel := t.mapObj.Level(levelNum)
-	if mapLevel == nil {
-		return nil
+	face := t.faceAt(x, y, side)
+	if tex := t.decalTextureAt(x, y, levelNum, face); tex != nil {
+		return tex
 	}
+	return t.wallTextureAt(x, y, levelNum, face)
+}
 
-	mapWidth := len(mapLevel)
-	if mapWidth == 0 {
+// wallTextureAt returns the wall texture of the face of the cell at the map position of a level,
+// the current frame for animated wall textures
+func (t *TextureHandler) wallTextureAt(x, y, levelNum int, face model.Face) *ebiten.Image {
+	texNum := t.mapObj.WallAt(levelNum, x, y, face) - 1 // 1 subtracted from it so that texture 0 can be used
+	if texNum < 0 || texNum >= len(t.textures) {
 		return nil
 	}
-	mapHeight := len(mapLevel[0])
-	if mapHeight == 0 {
-		return nil
+	if anim, ok := t.animations[texNum]; ok {
+		return anim.frames[anim.frame]
 	}
+	return t.textures[texNum]
+}
 
-	if x >= 0 && x < mapWidth && y >= 0 && y < mapHeight {
-		texNum = mapLevel[x][y] - 1 // 1 subtracted from it so that texture 0 can be used
+// faceAt returns the face of the cell seen from the view position, side 0 being an east or west face
+// and side 1 being a north or south face
+func (t *TextureHandler) faceAt(x, y, side int) model.Face {
+	if side == 0 {
+		if t.viewX < float64(x) {
+			return model.FaceWest
+		}
+		return model.FaceEast
 	}
+	if t.viewY < float64(y) {
+		return model.FaceNorth
+	}
+	return model.FaceSouth
+}
 
-	if side == 0 {
-		//--some supid hacks to make the houses render correctly--//
-		// this corrects textures on two sides of house since the textures are not symmetrical
-		if texNum == 3 {
-			texNum = 4
-		} else if texNum == 4 {
-			texNum = 3
+// loadDoorTextures creates the frames of the doors sliding open for each door texture of the map,
+// so the raycaster does not have to wait on new images being drawn while a door is moving
+func (t *TextureHandler) loadDoorTextures() {
+	t.doorFrames = make(map[int][]*ebiten.Image)
+	for _, door := range t.mapObj.Doors() {
+		texNum := door.TexNum - 1
+		if _, ok := t.doorFrames[texNum]; ok || texNum >= len(t.textures) || t.textures[texNum] == nil {
+			continue
 		}
 
-		if texNum == 1 {
-			texNum = 4
-		} else if texNum == 2 {
-			texNum = 3
+		tex := t.textures[texNum]
+		frames := make([]*ebiten.Image, numDoorFrames)
+		frames[0] = tex
+		for i := 1; i < numDoorFrames; i++ {
+			frame := ebiten.NewImage(texWidth, texWidth)
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(float64(texWidth*i/numDoorFrames), 0)
+			frame.DrawImage(tex, op)
+			frames[i] = frame
 		}
+		t.doorFrames[texNum] = frames
+	}
+}
 
-		// make the ebitengine splash only show on one side
-		if texNum == 5 {
-			texNum = 0
+// doorTexture returns the frame of the door texture slid open by the open amount (0 to 1)
+func (t *TextureHandler) doorTexture(texNum int, openAmount float64) *ebiten.Image {
+	frames := t.doorFrames[texNum]
+	if len(frames) == 0 {
+		if texNum < 0 || texNum >= len(t.textures) {
+			return nil
 		}
+		return t.textures[texNum]
 	}
 
-	if texNum < 0 {
-		return nil
+	frame := int(openAmount * float64(numDoorFrames))
+	if frame < 0 {
+		frame = 0
+	} else if frame >= numDoorFrames {
+		frame = numDoorFrames - 1
 	}
-	return t.textures[texNum]
+	return frames[frame]
 }
 
 func (t *TextureHandler) FloorTextureAt(x, y int) *image.RGBA {
-	// x/y could be used to render different floor texture at given coords,
-	// but for this demo we will just be rendering the same texture everywhere.
-	if t.renderFloorTex {
-		return t.floorTex
+	if !t.renderFloorTex {
+		return nil
+	}
+	if tex := t.floorTextureByNum(t.mapObj.FloorAt(x, y)); tex != nil {
+		return tex
+	}
+	return t.floorTex
+}
+
+func (t *TextureHandler) floorTextureByNum(value int) *image.RGBA {
+	texNum := value - 1 // 1 subtracted from it so that texture 0 can be used
+	if texNum < 0 || texNum >= len(t.floorTextures) {
+		return nil
 	}
-	return nil
+	return t.floorTextures[texNum]
 }
@@ -0,0 +1,119 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/harbdog/raycaster-go-demo/game/model"
+	"github.com/harbdog/raycaster-go/geom"
+)
+
+// updateTriggers fires the triggers of the map for the entities entering, leaving or staying in them
+func (g *Game) updateTriggers(dt float64) {
+	for _, trigger := range g.mapObj.Triggers() {
+		for _, entity := range trigger.Update(g.triggerEntities(trigger), dt) {
+			if !g.fireTrigger(trigger, entity) {
+				// the map changed, so the triggers of the next map are checked from the next update
+				return
+			}
+		}
+	}
+}
+
+// teleport moves the entity to the position and heading angle of the teleport action,
+// unless the entity would be inside a wall there
+func (g *Game) teleport(entity *model.Entity, action model.TriggerAction) {
+	minZ, maxZ := zEntityMinMax(action.Z, entity)
+	if g.isWallAt(int(action.X), int(action.Y), minZ, maxZ) {
+		fmt.Printf("Unable to teleport to (%v, %v) without headroom\n", action.X, action.Y)
+		return
+	}
+
+	entity.Position.X, entity.Position.Y = action.X, action.Y
+	entity.PositionZ = action.Z
+	entity.Slide = geom.Vector2{}
+	if action.Angle != nil {
+		entity.Angle = *action.Angle
+	}
+
+	if _, ok := g.spriteChunkOf[entity]; ok {
+		g.indexSprite(entity)
+	}
+	if entity == g.player.Entity {
+		g.updatePlayerCamera(true)
+	}
+}
+
+// triggerEntities returns the entities of the kinds the trigger fires for
+func (g *Game) triggerEntities(trigger *model.Trigger) []*model.Entity {
+	entities := []*model.Entity{}
+	if trigger.FiresFor(model.TriggerPlayer) {
+		entities = append(entities, g.player.Entity)
+	}
+	if trigger.FiresFor(model.TriggerSprite) {
+		for sprite := range g.sprites {
+			entities = append(entities, sprite.Entity)
+		}
+	}
+	if trigger.FiresFor(model.TriggerProjectile) {
+		for projectile := range g.projectiles {
+			entities = append(entities, projectile.Entity)
+		}
+	}
+	return entities
+}
+
+// fireTrigger does the actions of the trigger fired for the entity, returning false if an action changed the map
+func (g *Game) fireTrigger(trigger *model.Trigger, entity *model.Entity) bool {
+	for _, action := range trigger.Actions {
+		switch action.Type {
+		case model.ActionDoor:
+			if door := g.mapObj.DoorAt(int(action.X), int(action.Y)); door != nil {
+				door.Locked = false
+				door.Open()
+			}
+
+		case model.ActionSpawn:
+			sprite, err := g.newSpriteByType(action.Sprite, action.X, action.Y, 0)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			sprite.PositionZ = action.Z
+			g.addSprite(sprite)
+
+		case model.ActionEffect:
+			effect, err := g.newEffectByType(action.Effect, action.X, action.Y, action.Z)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			g.addEffect(effect)
+
+		case model.ActionLighting:
+			if action.Illumination != nil {
+				g.setGlobalIllumination(*action.Illumination)
+			}
+			if action.Falloff != nil {
+				g.setLightFalloff(*action.Falloff)
+			}
+
+		case model.ActionTeleport:
+			g.teleport(entity, action)
+
+		case model.ActionEnd:
+			if err := g.endLevel(); err != nil {
+				fmt.Println(err)
+				continue
+			}
+			return false
+
+		case model.ActionExit:
+			if err := g.exitLevel(action.Level); err != nil {
+				fmt.Println(err)
+				continue
+			}
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+//go:build !js
+// +build !js
+
+package game
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/harbdog/raycaster-go-demo/game/model"
+)
+
+// reloadDelay is how long to wait for file changes to settle before reloading,
+// since saving a file can cause several file system events
+const reloadDelay = 250 * time.Millisecond
+
+// watchResources watches the directory of the level file (and of the levels it exits to) and the texture
+// directories for changes, signalling the game loop to reload them. Only files on disk are watched,
+// so textures are only watched when loaded from the resources path, and generated maps are not watched.
+func (g *Game) watchResources() error {
+	dirs := make(map[string]struct{})
+
+	levelDir, err := g.levelDir()
+	if err != nil {
+		return err
+	}
+	if levelDir != "" {
+		dirs[levelDir] = struct{}{}
+	}
+
+	if g.resourcesPath != "" {
+		manifest, err := model.LoadTextureManifest(g.resources, textureManifest)
+		if err != nil {
+			return err
+		}
+		files := []string{textureManifest}
+		for _, entry := range manifest.Textures {
+			files = append(files, entry.File)
+		}
+		for _, file := range files {
+			absPath, err := filepath.Abs(filepath.Join(g.resourcesPath, filepath.FromSlash(file)))
+			if err != nil {
+				return err
+			}
+			dirs[filepath.Dir(absPath)] = struct{}{}
+		}
+	}
+
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+
+	g.watchLevel = func() error {
+		levelDir, err := g.levelDir()
+		if err != nil || levelDir == "" {
+			return err
+		}
+		// adding a directory already watched does nothing
+		return watcher.Add(levelDir)
+	}
+
+	g.reload = make(chan struct{}, 1)
+	timer := time.AfterFunc(reloadDelay, func() {
+		select {
+		case g.reload <- struct{}{}:
+		default:
+			// reload already pending
+		}
+	})
+	timer.Stop()
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+				timer.Reset(reloadDelay)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// levelDir returns the directory on disk of the current level file,
+// or an empty string if it is not on disk (embedded in the resources or a generated map)
+func (g *Game) levelDir() (string, error) {
+	if g.mapGenerate.Algorithm != "" {
+		return "", nil
+	}
+
+	var levelFile string
+	switch {
+	case g.mapFile != "":
+		// the level file system of a configured level file is the root of the file system
+		absPath, err := filepath.Abs(g.mapFile)
+		if err != nil {
+			return "", err
+		}
+		root := filepath.VolumeName(absPath) + string(filepath.Separator)
+		levelFile = filepath.Join(root, filepath.FromSlash(g.levelPath))
+	case g.resourcesPath != "":
+		levelFile = filepath.Join(g.resourcesPath, filepath.FromSlash(g.levelPath))
+	default:
+		return "", nil
+	}
+
+	absPath, err := filepath.Abs(levelFile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(absPath), nil
+}
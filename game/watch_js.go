@@ -0,0 +1,11 @@
+//go:build js
+// +build js
+
+package game
+
+import "errors"
+
+// watchResources is not supported in the browser, which has no resource files to watch
+func (g *Game) watchResources() error {
+	return errors.New("resources cannot be watched in the browser")
+}